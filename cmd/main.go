@@ -2,28 +2,196 @@ package main
 
 import (
 	"Basic_login/controllers"
+	"Basic_login/infrastructure"
 	"Basic_login/repository"
 	"Basic_login/usecase"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
 	"log"
+	"os"
 )
 
+// secretKeyEnv ชื่อ environment variable ที่เก็บกุญแจ (base64) สำหรับเข้ารหัส secret ที่เก็บไว้
+const secretKeyEnv = "BASIC_LOGIN_SECRET_KEY"
+
+// tokenKeysEnv ชื่อ environment variable ที่เก็บ path ของไฟล์กุญแจ (JSON) สำหรับลงลายมือชื่อ JWT
+const tokenKeysEnv = "BASIC_LOGIN_TOKEN_KEYS"
+
+// cliClientID คีย์ผู้เรียกที่ใช้จำกัดอัตราการเรียกของเซสชัน CLI
+const cliClientID = "cli"
+
+// reservedUsernamesEnv ชื่อ environment variable ที่เก็บ path ของไฟล์รายชื่อผู้ใช้ที่สงวนไว้เพิ่มเติม
+const reservedUsernamesEnv = "BASIC_LOGIN_RESERVED_USERNAMES"
+
 func main() {
-	// userRepo สร้าง instance ของ repository ข้อมูลผู้ใช้ในหน่วยความจำ (In-Memory)
-	userRepo := repository.NewInMemoryUserRepository(1000) // สร้าง repository สำหรับเก็บข้อมูลผู้ใช้ในหน่วยความจำ
+	// storage เลือกที่เก็บข้อมูลผู้ใช้ memory (หายเมื่อปิดโปรแกรม), file (เก็บลงไฟล์ที่ -data) หรือ sqlite (ฐานข้อมูล SQLite ที่ -data)
+	storage := flag.String("storage", "memory", "user storage backend: memory, file or sqlite")
+	dataPath := flag.String("data", "users.db", "path of the user data file when -storage=file or -storage=sqlite")
+	flag.Parse()
+	// secretKey กุญแจสำหรับเข้ารหัส secret ที่เก็บไว้ ต้องโหลดก่อนเปิดที่เก็บข้อมูลแบบถาวร
+	secretKey := loadSecretKey(*storage != "memory")
+
+	// userRepo สร้าง instance ของ repository ข้อมูลผู้ใช้ตามที่เก็บข้อมูลที่เลือก
+	// roleRepo และ groupRepo เก็บบทบาทและกลุ่ม ซึ่งต้องคงอยู่คู่กับข้อมูลผู้ใช้เมื่อใช้ที่เก็บข้อมูลแบบถาวร
+	var userRepo usecase.UserRepository
+	var roleRepo usecase.RoleRepository
+	var groupRepo usecase.GroupRepository
+	switch *storage {
+	case "memory":
+		userRepo = repository.NewInMemoryUserRepository()   // สร้าง repository สำหรับเก็บข้อมูลผู้ใช้ในหน่วยความจำ
+		roleRepo = repository.NewInMemoryRoleRepository()   // สร้าง repository สำหรับเก็บบทบาทและชุดสิทธิ์ในหน่วยความจำ
+		groupRepo = repository.NewInMemoryGroupRepository() // สร้าง repository สำหรับเก็บกลุ่มและสมาชิกในหน่วยความจำ
+	case "file":
+		fileRepo, err := repository.NewFileUserRepository(*dataPath) // เปิด repository ที่เก็บข้อมูลผู้ใช้ลงไฟล์
+		if err != nil {
+			log.Fatalf("Failed to open user data file: %v\n", err)
+		}
+		defer fileRepo.Close() // รวม journal เป็น snapshot และปลดล็อกไฟล์เมื่อจบโปรแกรม
+		userRepo = fileRepo
+		roleRepo, groupRepo = openAccessFiles(*dataPath)
+	case "sqlite":
+		sqlRepo, err := repository.OpenSQLiteUserRepository(*dataPath) // เปิดฐานข้อมูล SQLite และปรับ schema เป็นเวอร์ชันล่าสุด
+		if err != nil {
+			log.Fatalf("Failed to open user database: %v\n", err)
+		}
+		defer sqlRepo.Close() // ปิดการเชื่อมต่อฐานข้อมูลเมื่อจบโปรแกรม
+		userRepo = sqlRepo
+		roleRepo, groupRepo = openAccessFiles(*dataPath)
+	default:
+		log.Fatalf("Unknown storage backend %q\n", *storage)
+	}
+	// sessionRepo สร้าง instance ของ repository เซสชันในหน่วยความจำ (In-Memory)
+	sessionRepo := repository.NewInMemorySessionRepository() // สร้าง repository สำหรับเก็บเซสชันในหน่วยความจำ
+	// config การตั้งค่าของระบบ โดยกำหนดกุญแจสำหรับเข้ารหัส secret ของ TOTP
+	config := usecase.DefaultConfig()
+	config.SecretKey = secretKey
 	// userUsecase สร้าง instance ของ use case สำหรับจัดการกับผู้ใช้ โดยใช้ repository และการตั้ง
 	userUsecase := usecase.NewUserUsecase(
-		userRepo,                // ใช้สำหรับเก็บข้อมูลผู้ใช้ในหน่วยความจำ
-		usecase.DefaultConfig(), // ใช้สำหรับตั้งค่า Argon2 ในการเข้ารหัส
-		log.Default(),           // ใช้สำหรับการบันทึกข้อมูล (logging) ในระบบ ในกรณีที่เกิดข้อผิดพลาด
-		usecase.NewConstants(),  // ใช้สำหรับตั้งค่าค่าคงที่
+		userRepo,               // ใช้สำหรับเก็บข้อมูลผู้ใช้
+		sessionRepo,            // ใช้สำหรับเก็บเซสชันของผู้ใช้ในหน่วยความจำ
+		roleRepo,               // ใช้สำหรับเก็บบทบาทและชุดสิทธิ์
+		groupRepo,              // ใช้สำหรับเก็บกลุ่มของผู้ใช้
+		config,                 // ใช้สำหรับตั้งค่า Argon2 ในการเข้ารหัส
+		log.Default(),          // ใช้สำหรับการบันทึกข้อมูล (logging) ในระบบ ในกรณีที่เกิดข้อผิดพลาด
+		usecase.NewConstants(), // ใช้สำหรับตั้งค่าค่าคงที่
+	)
+	userUsecase.Notifier = infrastructure.NewWriterNotifier(os.Stdout) // ส่งการแจ้งเตือน เช่น โทเคนรีเซ็ตรหัสผ่าน ออกทาง stdout
+	// refreshRepo เก็บสถานะของ refresh token ในหน่วยความจำ และให้ userUsecase ยกเลิกโทเคนเมื่อเปลี่ยนรหัสผ่านหรือระงับบัญชี
+	refreshRepo := repository.NewInMemoryRefreshTokenRepository()
+	userUsecase.RefreshRepo = refreshRepo
+	// tokenService ออก access token และ refresh token ให้ผู้ใช้หลังเข้าสู่ระบบ
+	tokenService := usecase.NewTokenService(
+		loadTokenKeys(),       // กุญแจสำหรับลงลายมือชื่อ JWT
+		refreshRepo,           // ใช้สำหรับเก็บสถานะของ refresh token
+		userRepo,              // ใช้ดึงข้อมูลผู้ใช้ล่าสุดตอนหมุนเวียนโทเคน
+		config,                // ใช้สำหรับตั้งค่าอายุโทเคนและผู้ออกโทเคน
+		log.Default(),         // ใช้สำหรับการบันทึกข้อมูล
+		userUsecase.Constants, // ใช้ค่าคงที่ชุดเดียวกับ userUsecase
+	)
+	// chatUsecase สร้าง instance ของ use case สำหรับห้องสนทนา และสมัครรับเหตุการณ์เพื่อให้ผู้ใช้ใหม่เข้าร่วมห้องสนทนา
+	chatUsecase := usecase.NewChatUsecase(
+		repository.NewInMemoryChatRepository(1000), // ใช้สำหรับห้องสนทนาในหน่วยความจำ
+		userUsecase, // ใช้สำหรับตรวจสอบสิทธิ์การส่งข้อความ
+	)
+	chatUsecase.Subscribe(userUsecase.Events)
+	// authUsecase ครอบ userUsecase เพื่อจำกัดอัตราการเรียกการยืนยันตัวตนของเซสชัน CLI
+	authUsecase := usecase.NewRateLimitedUserUsecase(
+		userUsecase,                         // use case ที่ถูกครอบ
+		repository.NewInMemoryRateLimiter(), // ใช้สำหรับเก็บโควตาของผู้เรียกในหน่วยความจำ
+		log.Default(),                       // ใช้สำหรับบันทึกเมื่อผู้เรียกใช้โควตาหมด
 	)
+	// โหลดรายชื่อผู้ใช้ที่สงวนไว้เพิ่มเติมจากไฟล์ (ถ้ามี)
+	if path := os.Getenv(reservedUsernamesEnv); path != "" {
+		if err := userUsecase.Reserved.Load(path); err != nil {
+			log.Fatalf("Failed to load reserved usernames: %v\n", err)
+		}
+	}
+
+	// ลงทะเบียนผู้ใช้ใหม่ผ่านฟังก์ชัน CreateUser จาก controllers ซึ่งจำเป็นเฉพาะเมื่อยังไม่มีผู้ใช้ในระบบ
+	// หากมีผู้ใช้อยู่แล้ว (เช่น โหลดจากที่เก็บข้อมูลแบบถาวร) การลงทะเบียนเป็นทางเลือก และข้อผิดพลาดจะไม่หยุดโปรแกรม
+	existingUsers, err := controllers.GetExistingUsers(userUsecase) // ดึงผู้ใช้ที่มีอยู่แล้ว
+	if err != nil {
+		log.Fatalf("Failed to load users: %v\n", err)
+	}
+	if len(existingUsers) == 0 {
+		if err := controllers.CreateUser(authUsecase, cliClientID); err != nil { // สร้างผู้ใช้คนแรกของระบบ
+			log.Fatalf("Failed to create user: %v\n", err) // หากเกิดข้อผิดพลาดในการสร้างผู้ใช้ให้ล็อกข้อผิดพลาด
+		}
+	} else if controllers.Confirm("Register a new user? (y/n): ") {
+		if err := controllers.CreateUser(authUsecase, cliClientID); err != nil {
+			log.Printf("Failed to create user: %v\n", err) // ยังเข้าสู่ระบบด้วยผู้ใช้ที่มีอยู่แล้วได้
+		}
+	}
+
+	// เข้าสู่ระบบผ่านฟังก์ชัน Login จาก controllers ก่อนเข้าห้องแชท
+	user, err := controllers.Login(authUsecase, cliClientID) // เข้าสู่ระบบด้วยชื่อผู้ใช้และรหัสผ่าน
+	if err != nil {
+		log.Fatalf("Failed to login: %v\n", err) // หากเข้าสู่ระบบไม่สำเร็จให้ล็อกข้อผิดพลาด
+	}
+	if err := controllers.IssueTokens(tokenService, user); err != nil { // ออกโทเคนให้ผู้ใช้ที่เข้าสู่ระบบแล้ว
+		log.Printf("Failed to issue tokens: %v\n", err) // ยังใช้งานห้องแชทได้โดยไม่มีโทเคน
+	}
+
+	// เริ่มการสนทนาผ่านฟังก์ชัน StartChat ซึ่งใช้ chatUsecase และผู้ใช้ที่เข้าสู่ระบบแล้ว
+	controllers.StartChat(chatUsecase, user) // เริ่มการสนทนาสำหรับผู้ใช้
+}
 
-	// เรียกฟังก์ชัน CreateUser จาก controllers เพื่อสร้างผู้ใช้ใหม่หากมีข้อผิดพลาดจะถูกล็อก
-	err := controllers.CreateUser(userUsecase) // สร้างผู้ใช้ใหม่
+// openAccessFiles เปิดไฟล์บทบาท (<dataPath>.roles.json) และไฟล์กลุ่ม (<dataPath>.groups.json) ที่อยู่คู่กับข้อมูลผู้ใช้
+func openAccessFiles(dataPath string) (*repository.FileRoleRepository, *repository.FileGroupRepository) {
+	roleRepo, err := repository.NewFileRoleRepository(dataPath + ".roles.json")
+	if err != nil {
+		log.Fatalf("Failed to open role file: %v\n", err)
+	}
+	groupRepo, err := repository.NewFileGroupRepository(dataPath + ".groups.json")
 	if err != nil {
-		log.Fatalf("Failed to create user: %v\n", err) // หากเกิดข้อผิดพลาดในการสร้างผู้ใช้ให้ล็อกข้อผิดพลาด
+		log.Fatalf("Failed to open group file: %v\n", err)
+	}
+	return roleRepo, groupRepo
+}
+
+// loadSecretKey โหลดกุญแจขนาด 32 bytes จาก environment variable หากไม่ได้กำหนดจะสร้างกุญแจแบบสุ่ม
+// ซึ่งใช้ได้เฉพาะกับข้อมูลที่เก็บในหน่วยความจำ เพราะกุญแจจะเปลี่ยนทุกครั้งที่เริ่มโปรแกรม
+// เมื่อ required เป็น true (ที่เก็บข้อมูลแบบถาวร) และไม่ได้กำหนดกุญแจ โปรแกรมจะหยุดทำงานทันที
+// เพื่อไม่ให้ secret ของ TOTP และโทเคนที่เก็บไว้ถอดรหัสไม่ได้ในการเริ่มโปรแกรมครั้งถัดไป
+func loadSecretKey(required bool) []byte {
+	if encoded := os.Getenv(secretKeyEnv); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded) // ถอดรหัสกุญแจจาก base64
+		if err != nil || len(key) != 32 {
+			log.Fatalf("%s must be a base64-encoded 32-byte key\n", secretKeyEnv)
+		}
+		return key
+	}
+	if required {
+		log.Fatalf("%s must be set when using persistent storage\n", secretKeyEnv)
 	}
 
-	// เริ่มการสนทนาผ่านฟังก์ชัน StartChat ซึ่งใช้ userUsecase สำหรับจัดการกับผู้ใช้
-	controllers.StartChat(userUsecase) // เริ่มการสนทนาสำหรับผู้ใช้
+	key := make([]byte, 32)                   // สร้างกุญแจแบบสุ่ม
+	if _, err := rand.Read(key); err != nil { // อ่านข้อมูลแบบสุ่มลงใน key
+		log.Fatalf("Failed to generate secret key: %v\n", err)
+	}
+	return key
+}
+
+// loadTokenKeys โหลดกุญแจสำหรับลงลายมือชื่อ JWT จากไฟล์ที่กำหนดใน environment variable
+// หากไม่ได้กำหนดจะสร้างกุญแจ Ed25519 แบบสุ่ม ซึ่งโทเคนที่ออกจะใช้ไม่ได้หลังเริ่มโปรแกรมใหม่
+// (refresh token เก็บไว้ในหน่วยความจำอยู่แล้ว จึงหายไปพร้อมกันเมื่อปิดโปรแกรม)
+func loadTokenKeys() *usecase.KeySet {
+	if path := os.Getenv(tokenKeysEnv); path != "" {
+		keys, err := usecase.LoadKeySet(path) // โหลดกุญแจจากไฟล์ JSON
+		if err != nil {
+			log.Fatalf("Failed to load token keys: %v\n", err)
+		}
+		return keys
+	}
+
+	key, err := usecase.GenerateKey("cli-1", usecase.AlgEdDSA) // สร้างกุญแจแบบสุ่ม
+	if err != nil {
+		log.Fatalf("Failed to generate token key: %v\n", err)
+	}
+	keys := usecase.NewKeySet()
+	if err := keys.AddKey(key); err != nil { // กุญแจแรกจะถูกตั้งเป็นกุญแจที่ใช้งาน
+		log.Fatalf("Failed to add token key: %v\n", err)
+	}
+	return keys
 }
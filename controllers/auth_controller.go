@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"Basic_login/domain"
+	"Basic_login/infrastructure"
+	"Basic_login/usecase"
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// maxLoginAttempts จำนวนครั้งสูงสุดที่ผู้ใช้สามารถลองเข้าสู่ระบบได้
+const maxLoginAttempts = 3
+
+// Login ขอข้อมูลเข้าสู่ระบบจากผู้ใช้และตรวจสอบผ่าน RateLimitedUserUsecase โดยใช้โควตาของผู้เรียก clientID
+func Login(auth *usecase.RateLimitedUserUsecase, clientID string) (*domain.User, error) {
+	userUsecase := auth.Usecase         // ใช้สำหรับค่าคงที่และการตั้งค่า
+	reader := bufio.NewReader(os.Stdin) // สร้าง reader สำหรับอ่านข้อมูลจาก stdin
+
+	for attempt := 1; attempt <= maxLoginAttempts; attempt++ {
+		username, err := infrastructure.ReadInput(reader, infrastructure.Prompts["username"]) // อ่านชื่อผู้ใช้
+		if err != nil {
+			return nil, err
+		}
+
+		password, err := infrastructure.ReadInput(reader, infrastructure.Prompts["password"]) // อ่านรหัสผ่าน
+		if err != nil {
+			return nil, err
+		}
+
+		user, status, err := auth.Login(clientID, username, password) // เข้าสู่ระบบด้วยชื่อผู้ใช้และรหัสผ่าน
+		var secondFactor *usecase.SecondFactorRequiredError
+		if errors.As(err, &secondFactor) { // ผู้ใช้เปิดใช้การยืนยันตัวตนสองขั้นตอน
+			user, status, err = completeSecondFactor(auth, clientID, reader, secondFactor.Challenge)
+		}
+		var passwordChange *usecase.PasswordChangeRequiredError
+		if errors.As(err, &passwordChange) { // ผู้ดูแลรีเซ็ตรหัสผ่านไว้ ต้องเปลี่ยนรหัสผ่านก่อนใช้งาน
+			if user, err = changeRequiredPassword(userUsecase, reader, passwordChange.Challenge); err != nil {
+				return nil, err
+			}
+		}
+		if err == nil {
+			fmt.Printf("Welcome, %s.\n", user.Username) // แสดงข้อความเมื่อเข้าสู่ระบบสำเร็จ
+			return user, nil
+		}
+
+		if errors.Is(err, userUsecase.Constants.ErrRateLimited) { // ผู้เรียกใช้โควตาหมดแล้ว
+			return nil, fmt.Errorf("login failed: %w (retry after %s)", err, status.RetryAfter)
+		}
+		if !errors.Is(err, userUsecase.Constants.ErrInvalidPassword) && !errors.Is(err, userUsecase.Constants.ErrInvalidCredentials) && !errors.Is(err, userUsecase.Constants.ErrInvalidTOTPCode) && !errors.Is(err, userUsecase.Constants.ErrInvalidRecoveryCode) {
+			return nil, fmt.Errorf("login failed: %w", err) // คืนค่าข้อผิดพลาดอื่นๆ โดยไม่ลองใหม่
+		}
+		fmt.Printf("%v, please try again (%d/%d).\n", err, attempt, maxLoginAttempts) // แจ้งให้ผู้ใช้ลองใหม่
+	}
+
+	return nil, fmt.Errorf("login failed: %w", userUsecase.Constants.ErrInvalidPassword) // ลองครบจำนวนครั้งแล้วยังไม่สำเร็จ
+}
+
+// IssueTokens ออก access token และ refresh token ให้ผู้ใช้ที่เข้าสู่ระบบแล้ว และแสดงออกทาง stdout
+func IssueTokens(tokenService *usecase.TokenService, user *domain.User) error {
+	pair, err := tokenService.IssueTokens(user) // ออกคู่โทเคนใหม่
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Access token (expires %s):\n%s\n", pair.ExpiresAt.Format(time.RFC3339), pair.AccessToken) // แสดง access token พร้อมเวลาหมดอายุ
+	fmt.Printf("Refresh token:\n%s\n", pair.RefreshToken)                                                 // แสดง refresh token
+	return nil
+}
+
+// changeRequiredPassword ขอรหัสผ่านใหม่จากผู้ใช้ที่ถูกบังคับให้เปลี่ยนรหัสผ่าน และจบการเข้าสู่ระบบด้วย challenge
+func changeRequiredPassword(userUsecase *usecase.UserUsecase, reader *bufio.Reader, challenge string) (*domain.User, error) {
+	fmt.Println("You must change your password before continuing.") // แจ้งให้ผู้ใช้เปลี่ยนรหัสผ่าน
+
+	newPassword, err := infrastructure.ReadInput(reader, infrastructure.Prompts["new_password"]) // อ่านรหัสผ่านใหม่
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := userUsecase.CompletePasswordChange(challenge, newPassword) // เปลี่ยนรหัสผ่าน
+	if err != nil {
+		return nil, fmt.Errorf("error changing password: %w", err)
+	}
+	fmt.Println("Password changed successfully.") // แสดงข้อความเมื่อเปลี่ยนรหัสผ่านสำเร็จ
+	return user, nil
+}
+
+// completeSecondFactor ขอรหัส TOTP หรือรหัสกู้คืนจากผู้ใช้และยืนยันขั้นตอนที่สองของการเข้าสู่ระบบ
+func completeSecondFactor(auth *usecase.RateLimitedUserUsecase, clientID string, reader *bufio.Reader, challenge string) (*domain.User, domain.RateLimitStatus, error) {
+	code, err := infrastructure.ReadInput(reader, infrastructure.Prompts["totp_code"]) // อ่านรหัส TOTP หรือรหัสกู้คืน
+	if err != nil {
+		return nil, domain.RateLimitStatus{}, err
+	}
+
+	if len(code) != auth.Usecase.Config.TOTPDigits { // รหัสที่ยาวไม่เท่ารหัส TOTP ถือเป็นรหัสกู้คืน
+		return auth.CompleteSecondFactorWithRecoveryCode(clientID, challenge, code)
+	}
+	return auth.CompleteSecondFactor(clientID, challenge, code) // ยืนยันรหัส TOTP
+}
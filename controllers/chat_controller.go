@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"Basic_login/domain"
 	"Basic_login/infrastructure"
 	"Basic_login/usecase"
 	"bufio"
@@ -11,16 +12,12 @@ import (
 	"time"
 )
 
-// StartChat จัดการเซสชันแชท
-func StartChat(usecase *usecase.UserUsecase) {
-	reader := bufio.NewReader(os.Stdin)                                                   // สร้าง reader สำหรับอ่านข้อมูลจาก stdin
-	username, err := infrastructure.ReadInput(reader, infrastructure.Prompts["username"]) // อ่านชื่อผู้ใช้
-	if err != nil {
-		log.Println("Error:", err)
-		return
-	}
+// StartChat จัดการเซสชันแชทของผู้ใช้ที่เข้าสู่ระบบแล้ว
+func StartChat(chat *usecase.ChatUsecase, user *domain.User) {
+	reader := bufio.NewReader(os.Stdin) // สร้าง reader สำหรับอ่านข้อมูลจาก stdin
+	username := user.Username           // ใช้ชื่อผู้ใช้ที่ผ่านการยืนยันตัวตนแล้ว
 
-	fmt.Printf("%s has joined the chat.\n", username) // บันทึกข้อผิดพลาดถ้ามี
+	fmt.Printf("%s has joined the chat.\n", username) // แสดงข้อความเมื่อผู้ใช้เข้าร่วมห้องแชท
 
 	for {
 		message, err := infrastructure.ReadInput(reader, infrastructure.PromptMessage) // อ่านข้อความจากผู้ใช้
@@ -29,11 +26,15 @@ func StartChat(usecase *usecase.UserUsecase) {
 			continue
 		}
 
-		usecase.SendChatMessage(username, message)                                               // ส่งข้อความไปยังเซิร์ฟเวอร์
+		if err := chat.SendMessage(user, message); err != nil { // ส่งข้อความไปยังเซิร์ฟเวอร์
+			log.Println("Error:", err) // ผู้ใช้ไม่มีสิทธิ์ส่งข้อความ จึงออกจากห้องแชท
+			chat.Leave(username)
+			return
+		}
 		fmt.Printf("[%s] %s: %s\n", time.Now().Format("2006-01-02 15:04:05"), username, message) // แสดงข้อความในแชท
 
 		if Confirm(infrastructure.PromptLeaveChat) { // ถามผู้ใช้ว่าต้องการออกจากห้องแชทหรือไม่
-			usecase.LeaveChat(username)                     // ออกจากห้องแชท
+			chat.Leave(username)                            // ออกจากห้องแชท
 			fmt.Printf("%s has left the chat.\n", username) // แสดงข้อความเมื่อผู้ใช้ออกจากห้องแชท
 			break
 		}
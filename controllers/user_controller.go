@@ -9,8 +9,9 @@ import (
 	"os"
 )
 
-// CreateUser สร้างผู้ใช้ใหม่
-func CreateUser(usecase *usecase.UserUsecase) error {
+// CreateUser สร้างผู้ใช้ใหม่ผ่าน RateLimitedUserUsecase โดยใช้โควตาของผู้เรียก clientID
+func CreateUser(auth *usecase.RateLimitedUserUsecase, clientID string) error {
+	usecase := auth.Usecase             // ใช้สำหรับอ่านข้อมูลผู้ใช้ที่มีอยู่
 	reader := bufio.NewReader(os.Stdin) // สร้าง reader สำหรับอ่านข้อมูลจาก stdin
 
 	userSet, err := GetExistingUsers(usecase) // ดึงผู้ใช้ที่มีอยู่แล้ว
@@ -18,7 +19,12 @@ func CreateUser(usecase *usecase.UserUsecase) error {
 		return err
 	}
 
-	username, password, role, err := infrastructure.ReadUserInput(reader) //  อ่านข้อมูลผู้ใช้
+	username, password, role, err := infrastructure.ReadUserInput(reader, usecase) //  อ่านข้อมูลผู้ใช้
+	if err != nil {
+		return err
+	}
+
+	email, err := infrastructure.ReadInput(reader, infrastructure.Prompts["email"]) // อ่านอีเมล (ไม่บังคับ)
 	if err != nil {
 		return err
 	}
@@ -27,7 +33,7 @@ func CreateUser(usecase *usecase.UserUsecase) error {
 		return fmt.Errorf("username '%s' already exists", username) // ถ้ามีอยู่แล้วให้คืนค่าข้อผิดพลาด
 	}
 
-	if err := usecase.CreateUser(&domain.User{Username: username, Password: password}, role); err != nil { // สร้างผู้ใช้ใหม่
+	if _, err := auth.CreateUser(clientID, &domain.User{Username: username, Password: password, Email: email}, role); err != nil { // สร้างผู้ใช้ใหม่
 		return fmt.Errorf("error creating user: %w", err) // คืนค่าข้อผิดพลาด ถ้าสร้างไม่สำเร็จ
 	}
 
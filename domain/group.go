@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"slices"
+)
+
+// Group กลุ่มของผู้ใช้ เช่น ทีม ซึ่งสมาชิกทุกคนได้รับบทบาทและสิทธิ์ที่กำหนดให้กลุ่ม
+type Group struct {
+	ID          int64        // รหัสประจำตัวของกลุ่ม
+	Name        string       // ชื่อกลุ่ม
+	MemberIDs   []int64      // รหัสประจำตัวของสมาชิกในกลุ่ม
+	Roles       []string     // บทบาทที่กำหนดให้สมาชิกทุกคนในกลุ่ม
+	Permissions []Permission // สิทธิ์ที่กำหนดให้สมาชิกทุกคนในกลุ่มโดยตรง
+}
+
+// HasMember ตรวจสอบว่าผู้ใช้ userID เป็นสมาชิกของกลุ่มหรือไม่
+func (g *Group) HasMember(userID int64) bool {
+	return slices.Contains(g.MemberIDs, userID)
+}
+
+// EffectivePermissions สิทธิ์ทั้งหมดของผู้ใช้ที่รวมจากบทบาทของผู้ใช้ และบทบาทและสิทธิ์ของทุกกลุ่มที่เป็นสมาชิก
+type EffectivePermissions struct {
+	Permissions    []Permission // สิทธิ์ที่ใช้ได้กับทุก resource
+	OwnPermissions []Permission // สิทธิ์ที่ใช้ได้เฉพาะกับ resource ที่ตนเป็นเจ้าของ
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"slices"
+)
+
+// Permission สิทธิ์ในการดำเนินการหนึ่งอย่างในระบบ ในรูปแบบ "<resource>.<action>"
+type Permission string
+
+// สิทธิ์ที่ใช้ในระบบ
+const (
+	PermUserCreate        Permission = "user.create"         // สร้างผู้ใช้ใหม่ในนามผู้อื่น
+	PermUserUpdate        Permission = "user.update"         // แก้ไขข้อมูลผู้ใช้ เช่น ชื่อผู้ใช้และอีเมล
+	PermUserDelete        Permission = "user.delete"         // ลบผู้ใช้แบบ soft delete หรือลบถาวร
+	PermUserDisable       Permission = "user.disable"        // ระงับหรือเปิดใช้งานบัญชี
+	PermUserUnlock        Permission = "user.unlock"         // ปลดล็อกบัญชีที่ถูกล็อกจากการเข้าสู่ระบบไม่สำเร็จ
+	PermUserResetPassword Permission = "user.reset_password" // รีเซ็ตรหัสผ่านเป็นรหัสผ่านชั่วคราว
+	PermUserCredentials   Permission = "user.credentials"    // จัดการ TOTP รหัสกู้คืน และเซสชัน
+	PermUserReservedName  Permission = "user.reserved_name"  // ใช้ชื่อผู้ใช้ที่สงวนไว้
+	PermRoleAssign        Permission = "role.assign"         // กำหนดบทบาทให้ผู้ใช้
+	PermRoleManage        Permission = "role.manage"         // สร้าง แก้ไข และลบบทบาท
+	PermGroupManage       Permission = "group.manage"        // สร้างและลบกลุ่ม จัดการสมาชิก และกำหนดบทบาทและสิทธิ์ให้กลุ่ม
+	PermChatSend          Permission = "chat.send"           // ส่งข้อความในห้องสนทนา
+	PermChatModerate      Permission = "chat.moderate"       // ดูแลห้องสนทนา เช่น ลบข้อความของผู้อื่น
+	PermRoomCreate        Permission = "room.create"         // สร้างห้องสนทนา
+)
+
+// AllPermissions รายการสิทธิ์ทั้งหมดในระบบ
+var AllPermissions = []Permission{
+	PermUserCreate, PermUserUpdate, PermUserDelete, PermUserDisable, PermUserUnlock, PermUserResetPassword,
+	PermUserCredentials, PermUserReservedName, PermRoleAssign, PermRoleManage, PermGroupManage, PermChatSend,
+	PermChatModerate, PermRoomCreate,
+}
+
+// ชนิดของ resource ที่ใช้ตรวจสอบสิทธิ์
+const (
+	ResourceUser  = "user"  // ผู้ใช้
+	ResourceChat  = "chat"  // ห้องสนทนาหลัก
+	ResourceRoom  = "room"  // ห้องสนทนา
+	ResourceRole  = "role"  // บทบาท
+	ResourceGroup = "group" // กลุ่มของผู้ใช้
+)
+
+// Resource สิ่งที่ถูกดำเนินการ ใช้ตรวจสอบว่าผู้ดำเนินการเป็นเจ้าของหรือไม่
+type Resource struct {
+	Kind    string // ชนิดของ resource เช่น ResourceUser
+	ID      int64  // รหัสของ resource (0 คือไม่ระบุ)
+	OwnerID int64  // รหัสผู้ใช้ที่เป็นเจ้าของ resource (0 คือไม่มีเจ้าของ)
+}
+
+// UserResource คืนค่า Resource ของผู้ใช้ ซึ่งผู้ใช้เป็นเจ้าของบัญชีของตนเอง
+func UserResource(user *User) Resource {
+	return Resource{Kind: ResourceUser, ID: user.ID, OwnerID: user.ID}
+}
+
+// Role บทบาทและชุดสิทธิ์ของบทบาท
+type Role struct {
+	Name           string       // ชื่อบทบาท เช่น "admin"
+	Permissions    []Permission // สิทธิ์ที่ใช้ได้กับทุก resource
+	OwnPermissions []Permission // สิทธิ์ที่ใช้ได้เฉพาะกับ resource ที่ตนเป็นเจ้าของ
+	Inherits       []string     // ชื่อบทบาทที่สืบทอดสิทธิ์ทั้งหมดมา
+}
+
+// Allows ตรวจสอบว่าบทบาทมีสิทธิ์ permission โดยตรงหรือไม่ (ไม่รวมสิทธิ์ที่สืบทอด) โดย own ระบุว่าผู้ดำเนินการเป็นเจ้าของ resource
+func (r *Role) Allows(permission Permission, own bool) bool {
+	if slices.Contains(r.Permissions, permission) {
+		return true
+	}
+	return own && slices.Contains(r.OwnPermissions, permission)
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// RateLimit กำหนดขนาดและอัตราการเติมของ token bucket
+type RateLimit struct {
+	Capacity    int           // จำนวนครั้งสูงสุดที่เรียกได้ติดกัน (ขนาดของ bucket)
+	RefillEvery time.Duration // ระยะเวลาที่ใช้เติม token หนึ่งอัน
+}
+
+// RateLimitStatus สถานะโควตาของผู้เรียกหลังจากการตรวจสอบ
+type RateLimitStatus struct {
+	Allowed    bool          // อนุญาตให้เรียกใช้ครั้งนี้หรือไม่
+	Limit      int           // จำนวนครั้งสูงสุด (ขนาดของ bucket)
+	Remaining  int           // จำนวนครั้งที่เหลืออยู่
+	RetryAfter time.Duration // ระยะเวลาที่ต้องรอก่อนเรียกใหม่ได้ (ศูนย์หากยังมีโควตาเหลือ)
+}
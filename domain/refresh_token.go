@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+)
+
+// RefreshToken แทนสถานะของ refresh token ที่ออกให้ผู้ใช้
+type RefreshToken struct {
+	ID        string    // รหัสของ refresh token (jti)
+	Family    string    // รหัสกลุ่มของ refresh token ที่หมุนเวียนต่อกันมาจากการเข้าสู่ระบบครั้งเดียวกัน
+	UserID    int64     // รหัสประจำตัวของผู้ใช้ที่เป็นเจ้าของโทเคน
+	IssuedAt  time.Time // เวลาที่ออกโทเคน
+	ExpiresAt time.Time // เวลาที่โทเคนหมดอายุ
+	Used      bool      // ถูกใช้แลกโทเคนใหม่ไปแล้วหรือไม่
+	Revoked   bool      // ถูกยกเลิกแล้วหรือไม่
+}
@@ -0,0 +1,14 @@
+package domain
+
+import (
+	"time"
+)
+
+// Session แทนเซสชันของผู้ใช้ที่เข้าสู่ระบบแล้ว
+type Session struct {
+	ID         string    // รหัสเซสชัน (แฮช SHA-256 ของโทเคน) ไม่เก็บโทเคนจริงไว้ในระบบ
+	UserID     int64     // รหัสประจำตัวของผู้ใช้ที่เป็นเจ้าของเซสชัน
+	CreatedAt  time.Time // เวลาที่สร้างเซสชัน
+	LastSeenAt time.Time // เวลาที่มีการใช้งานเซสชันล่าสุด
+	ExpiresAt  time.Time // เวลาที่เซสชันหมดอายุ
+}
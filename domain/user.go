@@ -1,12 +1,67 @@
 package domain
 
+import (
+	"time"
+)
+
+// AccountStatus สถานะของบัญชีผู้ใช้
+type AccountStatus string
+
+// สถานะของบัญชีผู้ใช้
+const (
+	StatusActive   AccountStatus = "active"   // ใช้งานได้ตามปกติ
+	StatusDisabled AccountStatus = "disabled" // ถูกระงับโดยผู้ดูแลระบบ
+	StatusPending  AccountStatus = "pending"  // รอการเปิดใช้งาน
+	StatusDeleted  AccountStatus = "deleted"  // ถูกลบแบบ soft delete และรอลบถาวรเมื่อพ้นระยะเวลาเก็บรักษา
+)
+
+// UsernameChange บันทึกชื่อผู้ใช้เดิมเมื่อมีการเปลี่ยนชื่อ
+type UsernameChange struct {
+	Username  string    // ชื่อผู้ใช้เดิม
+	ChangedAt time.Time // เวลาที่เปลี่ยนชื่อ
+}
+
 // โครงสร้างข้อมูล User
 type User struct {
-	Salt     []byte // ใช้สำหรับเก็บค่า salt ที่ใช้ในการ hash รหัสผ่าน
+	Salt     []byte // ใช้สำหรับเก็บค่า salt ของแฮชรูปแบบเดิมเท่านั้น แฮชรูปแบบ PHC เก็บ salt ไว้ใน Password
 	Username string // เก็บชื่อผู้ใช้งาน
-	Password string // เก็บรหัสผ่าน
+	Password string // เก็บรหัสผ่านที่แฮชแล้ว ในรูปแบบ $argon2id$v=19$m=...,t=...,p=...$salt$hash
 	Role     string // บทบาทผู้ใช้ เช่น "admin", "user", "guest"
 	ID       int64  // รหัสประจำตัวผู้ใช้ การระบุผู้ใช้ในระบบ
+
+	PreviousUsernames []UsernameChange // ประวัติชื่อผู้ใช้เดิม เรียงจากเก่าไปใหม่
+
+	Status    AccountStatus // สถานะของบัญชี (ค่าว่างถือว่าเป็น StatusActive สำหรับข้อมูลเดิม)
+	DeletedAt time.Time     // เวลาที่บัญชีถูกลบแบบ soft delete
+
+	Email                      string    // อีเมลของผู้ใช้ (ไม่บังคับ) เก็บในรูปแบบที่ normalize แล้ว และต้องไม่ซ้ำกับผู้ใช้อื่น
+	EmailVerified              bool      // ยืนยันอีเมลแล้วหรือไม่
+	EmailVerificationHash      string    // แฮช SHA-256 ของโทเคนยืนยันอีเมลที่ยังไม่ถูกใช้
+	EmailVerificationExpiresAt time.Time // เวลาที่โทเคนยืนยันอีเมลหมดอายุ
+
+	MustChangePassword bool // ต้องเปลี่ยนรหัสผ่านในการเข้าสู่ระบบครั้งถัดไป เช่น หลังจากผู้ดูแลรีเซ็ตรหัสผ่าน
+
+	FailedLoginAttempts int       // จำนวนครั้งที่เข้าสู่ระบบไม่สำเร็จติดต่อกัน
+	LastFailedLoginAt   time.Time // เวลาที่เข้าสู่ระบบไม่สำเร็จครั้งล่าสุด
+	LockedUntil         time.Time // เวลาที่บัญชีจะถูกปลดล็อก (ค่าศูนย์คือไม่ถูกล็อก)
+
+	TOTPEnabled       bool   // เปิดใช้การยืนยันตัวตนสองขั้นตอนด้วย TOTP แล้วหรือไม่
+	TOTPSecret        []byte // secret ของ TOTP ที่ยืนยันแล้ว (เข้ารหัสด้วย AES-GCM)
+	TOTPPendingSecret []byte // secret ของ TOTP ที่รอการยืนยัน (เข้ารหัสด้วย AES-GCM)
+	TOTPLastCounter   int64  // ช่วงเวลา (counter) ของรหัส TOTP ที่ใช้ล่าสุด เพื่อป้องกันการใช้รหัสซ้ำ
+
+	RecoveryCodes []string // แฮชของรหัสกู้คืนแบบใช้ครั้งเดียวที่ยังไม่ถูกใช้ (รูปแบบเดียวกับ Password)
+
+	ResetTokenHash      string    // แฮช SHA-256 ของโทเคนรีเซ็ตรหัสผ่านที่ยังไม่ถูกใช้
+	ResetTokenExpiresAt time.Time // เวลาที่โทเคนรีเซ็ตรหัสผ่านหมดอายุ
+}
+
+// EffectiveStatus คืนค่าสถานะของบัญชี โดยข้อมูลเดิมที่ไม่มีสถานะถือว่าใช้งานได้
+func (u *User) EffectiveStatus() AccountStatus {
+	if u.Status == "" {
+		return StatusActive
+	}
+	return u.Status
 }
 
 //  effectively
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+)
+
+// UserEventType ชนิดของเหตุการณ์ที่เกิดกับบัญชีผู้ใช้
+type UserEventType string
+
+// ชนิดของเหตุการณ์
+const (
+	UserCreated UserEventType = "user.created" // สร้างผู้ใช้ใหม่สำเร็จ
+)
+
+// UserEvent เหตุการณ์ที่เกิดกับบัญชีผู้ใช้ ส่งให้ส่วนอื่นของระบบ เช่น ห้องสนทนา โดยไม่ต้องผูกกับที่เก็บข้อมูลผู้ใช้
+type UserEvent struct {
+	Type       UserEventType // ชนิดของเหตุการณ์
+	UserID     int64         // รหัสประจำตัวของผู้ใช้
+	Username   string        // ชื่อผู้ใช้ ณ เวลาที่เกิดเหตุการณ์
+	OccurredAt time.Time     // เวลาที่เกิดเหตุการณ์
+}
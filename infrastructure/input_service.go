@@ -10,21 +10,28 @@ const (
 	// คำถามสำหรับการป้อนข้อมูลของผู้ใช้
 	promptUsername  = "Enter username: "                            // คำถามสำหรับการป้อนชื่อผู้ใช้
 	promptPassword  = "Enter password: "                            // คำถามสำหรับการป้อนรหัสผ่าน
-	promptRole      = "Enter role (admin/user): "                   // คำถามสำหรับการป้อนบทบาท
+	promptNewPass   = "Enter new password: "                        // คำถามสำหรับการป้อนรหัสผ่านใหม่
+	promptTOTPCode  = "Enter authentication or recovery code: "     // คำถามสำหรับการป้อนรหัส TOTP หรือรหัสกู้คืน
+	promptEmail     = "Enter email (optional): "                    // คำถามสำหรับการป้อนอีเมล
+	promptRole      = "Enter role (e.g. admin/user): "              // คำถามสำหรับการป้อนบทบาท
 	promptContinue  = "Do you want to create another user? (y/n): " // คำถามสำหรับการสร้างผู้ใช้ใหม่
 	PromptMessage   = "Enter message: "                             // คำถามสำหรับการป้อนข้อความ
 	PromptLeaveChat = "Do you want to leave the chat? (y/n): "      // คำถามสำหรับการออกจากห้องสนทนา
-
-	// บทบาท
-	roleAdmin = "admin" // บทบาทผู้ดูแลระบบ
-	roleUser  = "user"  // บทบาทผู้ใช้ทั่วไป
 )
 
+// RoleRegistry แหล่งข้อมูลบทบาทที่ใช้ตรวจสอบบทบาทที่ผู้ใช้ป้อน เช่น usecase.UserUsecase
+type RoleRegistry interface {
+	IsValidRole(role string) bool // ตรวจสอบว่ามีบทบาทนี้อยู่ในระบบหรือไม่
+}
+
 // คำถามสำหรับการป้อนข้อมูลของผู้ใช้
 var Prompts = map[string]string{
-	"username": promptUsername, // ชื่อผู้ใช้
-	"password": promptPassword, // รหัสผ่าน
-	"role":     promptRole,     // บทบาท
+	"username":     promptUsername, // ชื่อผู้ใช้
+	"password":     promptPassword, // รหัสผ่าน
+	"new_password": promptNewPass,  // รหัสผ่านใหม่
+	"totp_code":    promptTOTPCode, // รหัส TOTP
+	"email":        promptEmail,    // อีเมล
+	"role":         promptRole,     // บทบาท
 }
 
 // ReadInput จัดการการป้อนข้อมูลของผู้ใช้
@@ -37,8 +44,8 @@ func ReadInput(reader *bufio.Reader, prompt string) (string, error) {
 	return strings.TrimSpace(input), nil // คืนค่าข้อมูลที่ถูกตัดช่องว่างที่ผู้ใช้ป้อน
 }
 
-// ReadUserInput รวบรวมข้อมูลผู้ใช้
-func ReadUserInput(reader *bufio.Reader) (string, string, string, error) {
+// ReadUserInput รวบรวมข้อมูลผู้ใช้ โดยตรวจสอบบทบาทกับ registry
+func ReadUserInput(reader *bufio.Reader, registry RoleRegistry) (string, string, string, error) {
 	username, err := ReadInput(reader, Prompts["username"]) // อ่านชื่อผู้ใช้
 	if err != nil {
 		return "", "", "", err // หากเกิดข้อผิดพลาดในการอ่านชื่อผู้ใช้คืนค่าข้อผิดพลาด
@@ -49,7 +56,7 @@ func ReadUserInput(reader *bufio.Reader) (string, string, string, error) {
 		return "", "", "", err // หากเกิดข้อผิดพลาดในการอ่านรหัสผ่านคืนค่าข้อผิดพลาด
 	}
 
-	role, err := ReadRole(reader) // อ่านบทบาท
+	role, err := ReadRole(reader, registry) // อ่านบทบาท
 	if err != nil {
 		return "", "", "", err // หากเกิดข้อผิดพลาดในการอ่านบทบาทคืนค่าข้อผิดพลาด
 	}
@@ -58,19 +65,19 @@ func ReadUserInput(reader *bufio.Reader) (string, string, string, error) {
 }
 
 // ReadRole ตรวจสอบบทบาทของผู้ใช้
-func ReadRole(reader *bufio.Reader) (string, error) {
+func ReadRole(reader *bufio.Reader, registry RoleRegistry) (string, error) {
 	role, err := ReadInput(reader, Prompts["role"]) // อ่านบทบาทจากผู้ใช้
 	if err != nil {
 		return "", err // หากเกิดข้อผิดพลาดในการอ่านคืนค่าข้อผิดพลาด
 	}
 
-	if !IsValidRole(role) { // ตรวจสอบว่าบทบาทที่ป้อนถูกต้องหรือไม่
-		return "", fmt.Errorf("invalid role: %s. Please enter 'admin' or 'user'", role) // หากบทบาทไม่ถูกต้องให้คืนค่าข้อผิดพลาด
+	if !IsValidRole(role, registry) { // ตรวจสอบว่าบทบาทที่ป้อนถูกต้องหรือไม่
+		return "", fmt.Errorf("invalid role: %s", role) // หากบทบาทไม่ถูกต้องให้คืนค่าข้อผิดพลาด
 	}
 	return role, nil // คืนค่าบทบาทที่ถูกต้อง
 }
 
-// IsValidRole ตรวจสอบว่าบทบาทที่ป้อนถูกต้องหรือไม่
-func IsValidRole(role string) bool {
-	return role == roleAdmin || role == roleUser // คืนค่าจริงหากบทบาทตรงกับ roleAdmin หรือ roleUser
+// IsValidRole ตรวจสอบว่าบทบาทที่ป้อนมีอยู่ใน registry หรือไม่
+func IsValidRole(role string, registry RoleRegistry) bool {
+	return registry.IsValidRole(role) // คืนค่าจริงหากมีบทบาทนี้อยู่ในระบบ
 }
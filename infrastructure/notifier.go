@@ -0,0 +1,77 @@
+package infrastructure
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WriterNotifier เขียนข้อความแจ้งเตือนลงใน io.Writer เช่น stdout หรือไฟล์
+type WriterNotifier struct {
+	mu     sync.Mutex // ใช้เพื่อไม่ให้ข้อความจากหลายเธรดเขียนปนกัน
+	writer io.Writer  // ปลายทางของข้อความ
+}
+
+// NewWriterNotifier สร้าง WriterNotifier ที่เขียนลงใน writer ที่กำหนด
+func NewWriterNotifier(writer io.Writer) *WriterNotifier {
+	return &WriterNotifier{writer: writer}
+}
+
+// NewFileNotifier สร้าง WriterNotifier ที่เขียนต่อท้ายไฟล์ที่กำหนด
+func NewFileNotifier(path string) (*WriterNotifier, *os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) // เปิดไฟล์ให้อ่านเขียนได้เฉพาะเจ้าของ
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewWriterNotifier(file), file, nil
+}
+
+// Send เขียนข้อความแจ้งเตือนลงใน writer
+func (n *WriterNotifier) Send(recipient, subject, body string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(n.writer, "[%s] To: %s\nSubject: %s\n\n%s\n", time.Now().Format("2006-01-02 15:04:05"), recipient, subject, body)
+	return err
+}
+
+// SMTPNotifier ส่งข้อความแจ้งเตือนทางอีเมลผ่านเซิร์ฟเวอร์ SMTP
+type SMTPNotifier struct {
+	Host string    // ชื่อโฮสต์ของเซิร์ฟเวอร์ SMTP
+	Port int       // พอร์ตของเซิร์ฟเวอร์ SMTP
+	From string    // อีเมลผู้ส่ง
+	Auth smtp.Auth // ข้อมูลยืนยันตัวตนกับเซิร์ฟเวอร์ (nil คือไม่ยืนยันตัวตน)
+}
+
+// NewSMTPNotifier สร้าง SMTPNotifier ใหม่ หาก username ว่างจะไม่ยืนยันตัวตนกับเซิร์ฟเวอร์
+func NewSMTPNotifier(host string, port int, from, username, password string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host) // PlainAuth ส่งรหัสผ่านได้เฉพาะผ่าน TLS หรือ localhost
+	}
+	return &SMTPNotifier{Host: host, Port: port, From: from, Auth: auth}
+}
+
+// Send ส่งอีเมลถึงผู้รับ
+func (n *SMTPNotifier) Send(recipient, subject, body string) error {
+	if strings.ContainsAny(recipient+subject, "\r\n") { // ป้องกันการแทรก header
+		return fmt.Errorf("invalid recipient or subject")
+	}
+
+	message := "From: " + n.From + "\r\n" +
+		"To: " + recipient + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	return smtp.SendMail(addr, n.Auth, n.From, []string{recipient}, []byte(message))
+}
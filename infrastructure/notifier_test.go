@@ -0,0 +1,144 @@
+package infrastructure_test
+
+import (
+	"Basic_login/infrastructure"
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// smtpSession สิ่งที่เซิร์ฟเวอร์ SMTP จำลองได้รับจากไคลเอนต์หนึ่งครั้ง
+type smtpSession struct {
+	from string   // อาร์กิวเมนต์ของคำสั่ง MAIL FROM
+	to   []string // อาร์กิวเมนต์ของคำสั่ง RCPT TO
+	data string   // เนื้อหาหลังคำสั่ง DATA โดยไม่รวมบรรทัด "."
+	err  error    // ข้อผิดพลาดในการอ่านหรือเขียนการเชื่อมต่อ
+}
+
+// startFakeSMTPServer เปิดเซิร์ฟเวอร์ SMTP จำลองที่รับการเชื่อมต่อหนึ่งครั้ง และส่งสิ่งที่ได้รับกลับทาง channel
+// เซิร์ฟเวอร์ไม่ประกาศ STARTTLS หรือ AUTH เพื่อให้ net/smtp ส่งอีเมลแบบข้อความธรรมดา
+func startFakeSMTPServer(t *testing.T) (host string, port int, sessions <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	result := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			result <- smtpSession{err: err}
+			return
+		}
+		defer conn.Close()
+		result <- serveSMTP(conn)
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, result
+}
+
+// serveSMTP ตอบคำสั่ง SMTP พื้นฐานจนกว่าไคลเอนต์จะส่ง QUIT
+func serveSMTP(conn net.Conn) smtpSession {
+	var session smtpSession
+	reader := bufio.NewReader(conn)
+	reply := func(line string) bool {
+		if _, err := conn.Write([]byte(line + "\r\n")); err != nil {
+			session.err = err
+			return false
+		}
+		return true
+	}
+
+	if !reply("220 localhost ESMTP") {
+		return session
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			session.err = err
+			return session
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			session.from = line[len("MAIL FROM:"):]
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			session.to = append(session.to, line[len("RCPT TO:"):])
+			reply("250 OK")
+		case command == "DATA":
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return session
+			}
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					session.err = err
+					return session
+				}
+				if dataLine == ".\r\n" { // จบเนื้อหาของอีเมล
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			session.data = data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return session
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+	host, port, sessions := startFakeSMTPServer(t)
+	notifier := infrastructure.NewSMTPNotifier(host, port, "noreply@example.com", "", "")
+
+	if err := notifier.Send("alice@example.com", "Password reset", "Your code is 123456\nIt expires soon."); err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+	session := <-sessions
+	if session.err != nil {
+		t.Fatalf("fake server error = %v", session.err)
+	}
+
+	if !strings.HasPrefix(session.from, "<noreply@example.com>") {
+		t.Errorf("MAIL FROM = %q, want <noreply@example.com>", session.from)
+	}
+	if len(session.to) != 1 || session.to[0] != "<alice@example.com>" {
+		t.Errorf("RCPT TO = %q, want [<alice@example.com>]", session.to)
+	}
+	for _, want := range []string{
+		"From: noreply@example.com\r\n",
+		"To: alice@example.com\r\n",
+		"Subject: Password reset\r\n",
+		"\r\n\r\nYour code is 123456\r\nIt expires soon.",
+	} {
+		if !strings.Contains(session.data, want) {
+			t.Errorf("message missing %q:\n%s", want, session.data)
+		}
+	}
+}
+
+func TestSMTPNotifierRejectsHeaderInjection(t *testing.T) {
+	notifier := infrastructure.NewSMTPNotifier("127.0.0.1", 1, "noreply@example.com", "", "") // ต้องถูกปฏิเสธก่อนเชื่อมต่อเซิร์ฟเวอร์
+
+	for _, tt := range []struct{ recipient, subject string }{
+		{"alice@example.com\r\nBcc: eve@example.com", "Hello"},
+		{"alice@example.com", "Hello\r\nBcc: eve@example.com"},
+	} {
+		if err := notifier.Send(tt.recipient, tt.subject, "body"); err == nil {
+			t.Errorf("Send(%q, %q) error = nil, want error", tt.recipient, tt.subject)
+		}
+	}
+}
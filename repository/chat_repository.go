@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"Basic_login/domain"
+)
+
+// InMemoryChatRepository ห้องสนทนาในหน่วยความจำ ซึ่งทำงานใน goroutine ของตัวเอง
+type InMemoryChatRepository struct {
+	chatRoom *domain.ChatRoom // ตัวแปรที่เก็บข้อมูลของห้องสนทนา
+}
+
+// NewInMemoryChatRepository สร้างห้องสนทนาพร้อมกับขนาด buffer ที่กำหนด และเริ่มการทำงานในพื้นหลัง
+func NewInMemoryChatRepository(bufferSize int) *InMemoryChatRepository {
+	repo := &InMemoryChatRepository{
+		chatRoom: domain.NewChatRoom(bufferSize), // สร้างห้องสนทนาพร้อมกับขนาด buffer ที่กำหนด
+	}
+	go repo.chatRoom.Run() // เริ่มการทำงานของห้องสนทนาในพื้นหลัง
+	return repo
+}
+
+// Join ให้ผู้ใช้เข้าร่วมห้องสนทนา
+func (repo *InMemoryChatRepository) Join(username string) {
+	repo.chatRoom.Join <- username // ส่งชื่อผู้ใช้ไปยัง channel Join ของห้องสนทนา
+}
+
+// Leave นำผู้ใช้ออกจากห้องสนทนา
+func (repo *InMemoryChatRepository) Leave(username string) {
+	repo.chatRoom.Leave <- username // ส่งชื่อผู้ใช้ไปยัง channel Leave ของห้องสนทนา
+}
+
+// Send ส่งข้อความไปยังห้องสนทนา
+func (repo *InMemoryChatRepository) Send(message domain.ChatMessage) {
+	repo.chatRoom.Messages <- message // ส่งข้อความไปยัง channel Messages ของห้องสนทนา
+}
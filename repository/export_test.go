@@ -0,0 +1,22 @@
+package repository
+
+import "time"
+
+// Abandon ปิดไฟล์และปลดล็อกโดยไม่รวม journal เป็น snapshot เพื่อจำลองโปรแกรมหยุดทำงานกะทันหันในการทดสอบ
+func (repo *FileUserRepository) Abandon() {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.closed = true
+	repo.journal.Close()
+	unlockFile(repo.lock)
+	repo.lock.Close()
+}
+
+// PruneNow ทำให้ Create ครั้งถัดไปลบโทเคนที่หมดอายุทันที โดยไม่ต้องรอ refreshTokenPruneInterval
+func (repo *InMemoryRefreshTokenRepository) PruneNow() {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.nextPrune = time.Time{}
+}
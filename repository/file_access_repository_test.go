@@ -0,0 +1,73 @@
+package repository_test
+
+import (
+	"Basic_login/domain"
+	"Basic_login/repository"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileRoleRepositoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.db.roles.json")
+	repo, err := repository.NewFileRoleRepository(path)
+	if err != nil {
+		t.Fatalf("NewFileRoleRepository error = %v", err)
+	}
+	if err := repo.Create(&domain.Role{Name: "auditor", Permissions: []domain.Permission{domain.PermUserUnlock}}); err != nil {
+		t.Fatalf("Create error = %v", err)
+	}
+	if err := repo.Create(&domain.Role{Name: "temp"}); err != nil {
+		t.Fatalf("Create error = %v", err)
+	}
+	if err := repo.Delete("temp"); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+
+	reopened, err := repository.NewFileRoleRepository(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	role, err := reopened.GetByName("auditor")
+	if err != nil {
+		t.Fatalf("GetByName(auditor) error = %v", err)
+	}
+	if !role.Allows(domain.PermUserUnlock, false) {
+		t.Errorf("reopened role permissions = %v, want %v", role.Permissions, domain.PermUserUnlock)
+	}
+	if _, err := reopened.GetByName("temp"); err != repository.ErrRoleNotFound {
+		t.Errorf("GetByName(temp) error = %v, want ErrRoleNotFound", err)
+	}
+}
+
+func TestFileGroupRepositoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.db.groups.json")
+	repo, err := repository.NewFileGroupRepository(path)
+	if err != nil {
+		t.Fatalf("NewFileGroupRepository error = %v", err)
+	}
+	first := &domain.Group{Name: "first"}
+	second := &domain.Group{Name: "second", MemberIDs: []int64{7}}
+	for _, group := range []*domain.Group{first, second} {
+		if err := repo.Create(group); err != nil {
+			t.Fatalf("Create(%s) error = %v", group.Name, err)
+		}
+	}
+	if err := repo.Delete(second.ID); err != nil { // ID ของกลุ่มที่ถูกลบต้องไม่ถูกใช้ซ้ำหลังเปิดไฟล์ใหม่
+		t.Fatalf("Delete error = %v", err)
+	}
+
+	reopened, err := repository.NewFileGroupRepository(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	if _, err := reopened.GetByID(first.ID); err != nil {
+		t.Fatalf("GetByID(first) error = %v", err)
+	}
+	third := &domain.Group{Name: "third"}
+	if err := reopened.Create(third); err != nil {
+		t.Fatalf("Create(third) error = %v", err)
+	}
+	if third.ID <= second.ID {
+		t.Errorf("new group ID = %d, want greater than deleted ID %d", third.ID, second.ID)
+	}
+}
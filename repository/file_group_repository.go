@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// groupFile รูปแบบของไฟล์กลุ่ม
+type groupFile struct {
+	NextID int64           `json:"next_id"` // ค่าตัวนับ ID ล่าสุด เพื่อไม่ให้ ID ของกลุ่มที่ถูกลบถูกใช้ซ้ำ
+	Groups []*domain.Group `json:"groups"`  // กลุ่มทั้งหมด เรียงตาม ID
+}
+
+// FileGroupRepository เก็บกลุ่มของผู้ใช้ลงไฟล์ JSON ในเครื่อง โดยใช้ข้อมูลในหน่วยความจำสำหรับการอ่าน
+// ทุกการเปลี่ยนแปลงจะเขียนกลุ่มทั้งหมดลงไฟล์ชั่วคราวแล้ว rename แทนที่ไฟล์เดิม เช่นเดียวกับ FileRoleRepository
+type FileGroupRepository struct {
+	mu   sync.Mutex               // ทำให้การเปลี่ยนแปลงและการเขียนไฟล์เกิดขึ้นทีละรายการ
+	mem  *InMemoryGroupRepository // ข้อมูลกลุ่มในหน่วยความจำ
+	path string                   // path ของไฟล์กลุ่ม
+}
+
+// NewFileGroupRepository เปิดหรือสร้างไฟล์กลุ่มที่ path และโหลดกลุ่มทั้งหมด
+func NewFileGroupRepository(path string) (*FileGroupRepository, error) {
+	repo := &FileGroupRepository{mem: NewInMemoryGroupRepository(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) { // ยังไม่มีไฟล์ เริ่มจากไม่มีกลุ่ม
+		return repo, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file groupFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("read group file: %w", err)
+	}
+	for _, group := range file.Groups {
+		repo.mem.put(group)
+	}
+	repo.mem.advanceCounter(file.NextID)
+	return repo, nil
+}
+
+// save เขียนกลุ่มทั้งหมดลงไฟล์ โดยผู้เรียกต้องถือ repo.mu อยู่แล้ว
+func (repo *FileGroupRepository) save() error {
+	groups, counter := repo.mem.snapshot()
+	data, err := json.Marshal(groupFile{NextID: counter, Groups: groups})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(repo.path, data)
+}
+
+// Create เพิ่มกลุ่มใหม่และบันทึกลงไฟล์ หากบันทึกไม่สำเร็จจะย้อนกลับการเพิ่มกลุ่ม
+func (repo *FileGroupRepository) Create(group *domain.Group) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if err := repo.mem.Create(group); err != nil {
+		return err
+	}
+	if err := repo.save(); err != nil {
+		repo.mem.Delete(group.ID) // ย้อนกลับ เพื่อให้ข้อมูลในหน่วยความจำตรงกับไฟล์
+		return err
+	}
+	return nil
+}
+
+// GetByID ดึงกลุ่มตามรหัสประจำตัว
+func (repo *FileGroupRepository) GetByID(id int64) (*domain.Group, error) {
+	return repo.mem.GetByID(id)
+}
+
+// GetAll ดึงกลุ่มทั้งหมด เรียงตามรหัสประจำตัว
+func (repo *FileGroupRepository) GetAll() ([]*domain.Group, error) {
+	return repo.mem.GetAll()
+}
+
+// GetByMember ดึงกลุ่มทั้งหมดที่ผู้ใช้ userID เป็นสมาชิก
+func (repo *FileGroupRepository) GetByMember(userID int64) ([]*domain.Group, error) {
+	return repo.mem.GetByMember(userID)
+}
+
+// Update ปรับปรุงข้อมูลกลุ่มและบันทึกลงไฟล์ หากบันทึกไม่สำเร็จจะย้อนกลับเป็นข้อมูลเดิม
+func (repo *FileGroupRepository) Update(group *domain.Group) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	previous, err := repo.mem.GetByID(group.ID)
+	if err != nil {
+		return err
+	}
+	if err := repo.mem.Update(group); err != nil {
+		return err
+	}
+	if err := repo.save(); err != nil {
+		repo.mem.put(previous)
+		return err
+	}
+	return nil
+}
+
+// Delete ลบกลุ่มและบันทึกลงไฟล์ หากบันทึกไม่สำเร็จจะย้อนกลับการลบ
+func (repo *FileGroupRepository) Delete(id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	previous, err := repo.mem.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := repo.mem.Delete(id); err != nil {
+		return err
+	}
+	if err := repo.save(); err != nil {
+		repo.mem.put(previous)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build !unix && !windows
+
+package repository
+
+import (
+	"os"
+)
+
+// lockFile ไม่รองรับการล็อกไฟล์บนแพลตฟอร์มนี้ ผู้ใช้ต้องไม่เปิดไฟล์เดียวกันจากหลายโปรเซส
+func lockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile ไม่ต้องทำอะไรบนแพลตฟอร์มนี้
+func unlockFile(file *os.File) error {
+	return nil
+}
+
+// syncDir ไม่ต้องทำอะไรบนแพลตฟอร์มนี้
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package repository
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile ล็อกไฟล์แบบ exclusive โดยไม่รอ หากโปรเซสอื่นถือล็อกอยู่จะคืนค่าข้อผิดพลาดทันที
+func lockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// unlockFile ปลดล็อกไฟล์
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// syncDir fsync ไดเรกทอรี เพื่อให้การสร้างหรือ rename ไฟล์ในไดเรกทอรีคงอยู่หลังไฟดับ
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
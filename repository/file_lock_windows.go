@@ -0,0 +1,24 @@
+//go:build windows
+
+package repository
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile ล็อกไฟล์แบบ exclusive โดยไม่รอ หากโปรเซสอื่นถือล็อกอยู่จะคืนค่าข้อผิดพลาดทันที
+func lockFile(file *os.File) error {
+	return windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &windows.Overlapped{})
+}
+
+// unlockFile ปลดล็อกไฟล์
+func unlockFile(file *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
+
+// syncDir ไม่ต้องทำอะไรบน Windows เพราะไม่รองรับการ fsync ไดเรกทอรี และ rename มีผลทันที
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileRoleRepository เก็บบทบาทลงไฟล์ JSON ในเครื่อง โดยใช้ข้อมูลในหน่วยความจำสำหรับการอ่าน
+// ทุกการเปลี่ยนแปลงจะเขียนบทบาททั้งหมดลงไฟล์ชั่วคราวแล้ว rename แทนที่ไฟล์เดิม เพราะจำนวนบทบาทมีน้อย
+// ไฟล์นี้ไม่มีการล็อกของตัวเอง จึงควรวางไว้คู่กับ FileUserRepository หรือฐานข้อมูลที่ป้องกันการเปิดจากหลายโปรเซสอยู่แล้ว
+type FileRoleRepository struct {
+	mu   sync.Mutex              // ทำให้การเปลี่ยนแปลงและการเขียนไฟล์เกิดขึ้นทีละรายการ
+	mem  *InMemoryRoleRepository // ข้อมูลบทบาทในหน่วยความจำ
+	path string                  // path ของไฟล์บทบาท
+}
+
+// NewFileRoleRepository เปิดหรือสร้างไฟล์บทบาทที่ path และโหลดบทบาททั้งหมด
+func NewFileRoleRepository(path string) (*FileRoleRepository, error) {
+	repo := &FileRoleRepository{mem: NewInMemoryRoleRepository(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) { // ยังไม่มีไฟล์ เริ่มจากไม่มีบทบาท
+		return repo, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var roles []*domain.Role
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("read role file: %w", err)
+	}
+	for _, role := range roles {
+		repo.mem.put(role)
+	}
+	return repo, nil
+}
+
+// save เขียนบทบาททั้งหมดลงไฟล์ โดยผู้เรียกต้องถือ repo.mu อยู่แล้ว
+func (repo *FileRoleRepository) save() error {
+	roles, err := repo.mem.GetAll()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(roles)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(repo.path, data)
+}
+
+// Create เพิ่มบทบาทใหม่และบันทึกลงไฟล์ หากบันทึกไม่สำเร็จจะย้อนกลับการเพิ่มบทบาท
+func (repo *FileRoleRepository) Create(role *domain.Role) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if err := repo.mem.Create(role); err != nil {
+		return err
+	}
+	if err := repo.save(); err != nil {
+		repo.mem.Delete(role.Name) // ย้อนกลับ เพื่อให้ข้อมูลในหน่วยความจำตรงกับไฟล์
+		return err
+	}
+	return nil
+}
+
+// GetByName ดึงบทบาทตามชื่อบทบาท
+func (repo *FileRoleRepository) GetByName(name string) (*domain.Role, error) {
+	return repo.mem.GetByName(name)
+}
+
+// GetAll ดึงบทบาททั้งหมด เรียงตามชื่อบทบาท
+func (repo *FileRoleRepository) GetAll() ([]*domain.Role, error) {
+	return repo.mem.GetAll()
+}
+
+// Update ปรับปรุงบทบาทและบันทึกลงไฟล์ หากบันทึกไม่สำเร็จจะย้อนกลับเป็นข้อมูลเดิม
+func (repo *FileRoleRepository) Update(role *domain.Role) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	previous, err := repo.mem.GetByName(role.Name)
+	if err != nil {
+		return err
+	}
+	if err := repo.mem.Update(role); err != nil {
+		return err
+	}
+	if err := repo.save(); err != nil {
+		repo.mem.put(previous)
+		return err
+	}
+	return nil
+}
+
+// Delete ลบบทบาทและบันทึกลงไฟล์ หากบันทึกไม่สำเร็จจะย้อนกลับการลบ
+func (repo *FileRoleRepository) Delete(name string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	previous, err := repo.mem.GetByName(name)
+	if err != nil {
+		return err
+	}
+	if err := repo.mem.Delete(name); err != nil {
+		return err
+	}
+	if err := repo.save(); err != nil {
+		repo.mem.put(previous)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,433 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Error messages
+var (
+	// ErrCorruptJournal ใช้เมื่อพบข้อมูลเสียหายใน journal ที่ไม่ใช่ระเบียนสุดท้าย ซึ่งกู้คืนเองไม่ได้
+	ErrCorruptJournal = errors.New("user journal is corrupt")
+	// ErrStoreLocked ใช้เมื่อไฟล์ข้อมูลผู้ใช้ถูกเปิดใช้งานโดยโปรเซสอื่นอยู่
+	ErrStoreLocked = errors.New("user data file is locked by another process")
+)
+
+// ชนิดของระเบียนใน journal
+const (
+	journalPut    = "put"    // เพิ่มหรือแทนที่ผู้ใช้ทั้งระเบียน
+	journalDelete = "delete" // ลบผู้ใช้ตาม ID
+)
+
+// defaultCompactEvery จำนวนระเบียนใน journal ก่อนรวมเข้ากับ snapshot โดยอัตโนมัติ
+const defaultCompactEvery = 1000
+
+// journalRecord ระเบียนหนึ่งรายการใน journal แต่ละระเบียนเก็บข้อมูลผู้ใช้ทั้งหมด จึงเล่นซ้ำกี่ครั้งก็ได้ผลเหมือนเดิม
+type journalRecord struct {
+	Op   string       `json:"op"`             // ชนิดของระเบียน journalPut หรือ journalDelete
+	ID   int64        `json:"id"`             // รหัสประจำตัวของผู้ใช้
+	User *domain.User `json:"user,omitempty"` // ข้อมูลผู้ใช้สำหรับ journalPut
+}
+
+// snapshotFile รูปแบบของไฟล์ snapshot
+type snapshotFile struct {
+	NextID int64          `json:"next_id"` // ค่าตัวนับ ID ล่าสุด เพื่อไม่ให้ ID ของผู้ใช้ที่ถูกลบถูกใช้ซ้ำ
+	Users  []*domain.User `json:"users"`   // ผู้ใช้ทั้งหมด เรียงตาม ID
+}
+
+// FileUserRepository เก็บผู้ใช้ลงไฟล์ในเครื่อง โดยใช้ข้อมูลในหน่วยความจำสำหรับการอ่าน และบันทึกทุกการเปลี่ยนแปลง
+// ลง journal (<path>.wal) พร้อม fsync ก่อนคืนค่าให้ผู้เรียก เมื่อ journal มีระเบียนครบ CompactEvery รายการ
+// จะรวมข้อมูลทั้งหมดเป็น snapshot (<path>) ด้วยการเขียนไฟล์ชั่วคราวแล้ว rename แทนที่ไฟล์เดิม
+// ไฟล์ <path>.lock ถูกล็อกไว้ตลอดเวลาที่เปิดใช้งาน เพื่อป้องกันไม่ให้หลายโปรเซสเขียนไฟล์เดียวกัน
+type FileUserRepository struct {
+	CompactEvery int // จำนวนระเบียนใน journal ก่อนรวมเป็น snapshot (0 คือไม่รวมอัตโนมัติ)
+
+	mu      sync.Mutex              // ทำให้การเปลี่ยนแปลงและการเขียน journal เกิดขึ้นทีละรายการ
+	mem     *InMemoryUserRepository // ข้อมูลผู้ใช้ในหน่วยความจำ ใช้สำหรับการอ่านและตรวจสอบความซ้ำ
+	path    string                  // path ของไฟล์ snapshot
+	journal *os.File                // ไฟล์ journal ที่เปิดไว้สำหรับเขียนต่อท้าย
+	lock    *os.File                // ไฟล์ล็อกที่ถือไว้ตลอดเวลาที่เปิดใช้งาน
+	records int                     // จำนวนระเบียนใน journal ตั้งแต่ snapshot ล่าสุด
+	closed  bool                    // ปิดการใช้งานแล้วหรือไม่
+}
+
+// NewFileUserRepository เปิดหรือสร้างไฟล์ข้อมูลผู้ใช้ที่ path โหลด snapshot และเล่น journal ซ้ำ
+// หากระเบียนสุดท้ายของ journal เขียนไม่ครบ (เช่น โปรแกรมหยุดทำงานระหว่างเขียน) จะตัดระเบียนนั้นทิ้ง
+func NewFileUserRepository(path string) (*FileUserRepository, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600) // เปิดไฟล์ล็อก
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil { // ล็อกไฟล์ หากโปรเซสอื่นถือไว้อยู่ให้คืนค่าข้อผิดพลาด
+		lock.Close()
+		return nil, fmt.Errorf("%w: %v", ErrStoreLocked, err)
+	}
+
+	repo := &FileUserRepository{
+		CompactEvery: defaultCompactEvery,
+		mem:          NewInMemoryUserRepository(),
+		path:         path,
+		lock:         lock,
+	}
+	if err := repo.load(); err != nil {
+		unlockFile(lock)
+		lock.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// load โหลด snapshot และเล่น journal ซ้ำ แล้วเปิด journal ไว้สำหรับเขียนต่อท้าย
+func (repo *FileUserRepository) load() error {
+	data, err := os.ReadFile(repo.path) // โหลด snapshot (ถ้ามี)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		var snapshot snapshotFile
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return fmt.Errorf("read user snapshot: %w", err)
+		}
+		for _, user := range snapshot.Users {
+			repo.mem.put(user)
+		}
+		repo.mem.advanceCounter(snapshot.NextID)
+	}
+
+	journal, err := os.OpenFile(repo.path+".wal", os.O_CREATE|os.O_RDWR, 0o600) // เปิด journal
+	if err != nil {
+		return err
+	}
+	if err := repo.replay(journal); err != nil {
+		journal.Close()
+		return err
+	}
+	if _, err := journal.Seek(0, io.SeekEnd); err != nil { // เขียนต่อท้ายระเบียนสุดท้ายที่สมบูรณ์
+		journal.Close()
+		return err
+	}
+	repo.journal = journal
+	return nil
+}
+
+// replay เล่นระเบียนใน journal ซ้ำตามลำดับ และตัดระเบียนสุดท้ายที่เขียนไม่ครบทิ้ง
+func (repo *FileUserRepository) replay(journal *os.File) error {
+	reader := bufio.NewReader(journal)
+	var offset int64 // ตำแหน่งสิ้นสุดของระเบียนสุดท้ายที่สมบูรณ์
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 { // ระเบียนสุดท้ายไม่มีบรรทัดใหม่ปิดท้าย แสดงว่าเขียนไม่ครบ
+				return repo.truncateJournal(journal, offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		record, ok := decodeJournalLine(line)
+		if !ok {
+			if _, err := reader.Peek(1); errors.Is(err, io.EOF) { // เสียหายเฉพาะระเบียนสุดท้าย กู้คืนได้
+				return repo.truncateJournal(journal, offset)
+			}
+			return fmt.Errorf("%w at offset %d", ErrCorruptJournal, offset)
+		}
+
+		repo.apply(record)
+		offset += int64(len(line))
+		repo.records++
+	}
+}
+
+// truncateJournal ตัด journal ที่ตำแหน่ง offset และ fsync เพื่อให้การกู้คืนคงอยู่
+func (repo *FileUserRepository) truncateJournal(journal *os.File, offset int64) error {
+	log.Printf("Recovering user journal: discarding torn record at offset %d", offset) // บันทึกการกู้คืนใน log
+	if err := journal.Truncate(offset); err != nil {
+		return err
+	}
+	return journal.Sync()
+}
+
+// apply นำระเบียนจาก journal ไปใช้กับข้อมูลในหน่วยความจำ
+func (repo *FileUserRepository) apply(record journalRecord) {
+	switch record.Op {
+	case journalPut:
+		repo.mem.put(record.User)
+	case journalDelete:
+		repo.mem.remove(record.ID)
+	}
+}
+
+// encodeJournalLine เข้ารหัสระเบียนเป็นหนึ่งบรรทัดในรูปแบบ "<crc32 ของ JSON> <JSON>\n"
+func encodeJournalLine(record journalRecord) ([]byte, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	line := make([]byte, 0, len(payload)+10)
+	line = fmt.Appendf(line, "%08x ", crc32.ChecksumIEEE(payload))
+	line = append(line, payload...)
+	return append(line, '\n'), nil
+}
+
+// decodeJournalLine ถอดรหัสหนึ่งบรรทัดของ journal และตรวจสอบ checksum
+func decodeJournalLine(line []byte) (journalRecord, bool) {
+	var record journalRecord
+	checksum, payload, found := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+	if !found {
+		return record, false
+	}
+	expected, err := strconv.ParseUint(string(checksum), 16, 32)
+	if err != nil || uint32(expected) != crc32.ChecksumIEEE(payload) {
+		return record, false
+	}
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return record, false
+	}
+	if record.Op == journalPut && record.User == nil {
+		return record, false
+	}
+	return record, record.Op == journalPut || record.Op == journalDelete
+}
+
+// appendLocked เขียนระเบียนต่อท้าย journal และ fsync โดยผู้เรียกต้องถือ repo.mu อยู่แล้ว
+func (repo *FileUserRepository) appendLocked(record journalRecord) error {
+	if repo.closed {
+		return os.ErrClosed
+	}
+	line, err := encodeJournalLine(record)
+	if err != nil {
+		return err
+	}
+	offset, err := repo.journal.Seek(0, io.SeekCurrent) // ตำแหน่งก่อนเขียน ใช้ย้อนกลับเมื่อเขียนไม่สำเร็จ
+	if err != nil {
+		return err
+	}
+	if _, err := repo.journal.Write(line); err != nil {
+		repo.rollbackJournal(offset)
+		return err
+	}
+	if err := repo.journal.Sync(); err != nil { // ต้องอยู่บนดิสก์ก่อนคืนค่าให้ผู้เรียก
+		repo.rollbackJournal(offset)
+		return err
+	}
+
+	repo.records++
+	if repo.CompactEvery > 0 && repo.records >= repo.CompactEvery {
+		if err := repo.compactLocked(); err != nil { // ข้อมูลอยู่ใน journal แล้ว จึงบันทึกข้อผิดพลาดไว้และลองใหม่ครั้งถัดไป
+			log.Printf("Failed to compact user journal: %v", err)
+		}
+	}
+	return nil
+}
+
+// rollbackJournal ตัดระเบียนที่เขียนไม่ครบทิ้งและย้ายตำแหน่งเขียนกลับไปที่ offset
+// เพื่อไม่ให้ระเบียนที่เสียหายค้างอยู่กลาง journal และทำให้เปิดไฟล์ครั้งถัดไปไม่ได้
+func (repo *FileUserRepository) rollbackJournal(offset int64) {
+	if err := repo.journal.Truncate(offset); err != nil {
+		log.Printf("Failed to roll back user journal at offset %d: %v", offset, err)
+		return
+	}
+	if _, err := repo.journal.Seek(offset, io.SeekStart); err != nil {
+		log.Printf("Failed to roll back user journal at offset %d: %v", offset, err)
+	}
+}
+
+// Compact รวมข้อมูลทั้งหมดเป็น snapshot ใหม่และล้าง journal
+func (repo *FileUserRepository) Compact() error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.closed {
+		return os.ErrClosed
+	}
+	return repo.compactLocked()
+}
+
+// compactLocked เขียน snapshot ลงไฟล์ชั่วคราว fsync แล้ว rename แทนที่ snapshot เดิม จากนั้นจึงล้าง journal
+// หากโปรแกรมหยุดทำงานก่อนล้าง journal การเล่น journal ซ้ำกับ snapshot ใหม่ก็ยังได้ผลเหมือนเดิม
+func (repo *FileUserRepository) compactLocked() error {
+	users, counter := repo.mem.snapshot()
+	data, err := json.Marshal(snapshotFile{NextID: counter, Users: users})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(repo.path, data); err != nil {
+		return err
+	}
+
+	if err := repo.journal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := repo.journal.Seek(0, io.SeekStart); err != nil { // Truncate ไม่ย้ายตำแหน่งเขียน หากไม่ย้อนกลับระเบียนถัดไปจะอยู่หลังช่องว่าง NUL
+		return err
+	}
+	if err := repo.journal.Sync(); err != nil {
+		return err
+	}
+	repo.records = 0
+	return nil
+}
+
+// writeFileAtomic เขียนข้อมูลลงไฟล์ชั่วคราวในไดเรกทอรีเดียวกัน fsync แล้ว rename แทนที่ไฟล์ path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // ลบไฟล์ชั่วคราวหาก rename ไม่สำเร็จ
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path)) // fsync ไดเรกทอรี เพื่อให้การ rename คงอยู่
+}
+
+// Close รวมข้อมูลเป็น snapshot ปิด journal และปลดล็อกไฟล์ให้โปรเซสอื่นใช้งานได้
+func (repo *FileUserRepository) Close() error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.closed {
+		return nil
+	}
+	repo.closed = true
+
+	err := repo.compactLocked()
+	if closeErr := repo.journal.Close(); err == nil {
+		err = closeErr
+	}
+	unlockFile(repo.lock)
+	if closeErr := repo.lock.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// GetByID ดึงข้อมูลผู้ใช้ตามรหัสประจำตัว (ID)
+func (repo *FileUserRepository) GetByID(id int64) (*domain.User, error) {
+	return repo.mem.GetByID(id)
+}
+
+// GetByUsername ดึงข้อมูลผู้ใช้ตามชื่อผู้ใช้
+func (repo *FileUserRepository) GetByUsername(username string) (*domain.User, error) {
+	return repo.mem.GetByUsername(username)
+}
+
+// GetByEmail ดึงข้อมูลผู้ใช้ตามอีเมลที่ normalize แล้ว
+func (repo *FileUserRepository) GetByEmail(email string) (*domain.User, error) {
+	return repo.mem.GetByEmail(email)
+}
+
+// GetAll ดึงข้อมูลผู้ใช้ทั้งหมด
+func (repo *FileUserRepository) GetAll() ([]*domain.User, error) {
+	return repo.mem.GetAll()
+}
+
+// Create เพิ่มผู้ใช้ใหม่และบันทึกลง journal หากบันทึกไม่สำเร็จจะย้อนกลับการเพิ่มผู้ใช้
+func (repo *FileUserRepository) Create(user *domain.User) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.closed {
+		return os.ErrClosed
+	}
+	if err := repo.mem.Create(user); err != nil {
+		return err
+	}
+	if err := repo.appendLocked(journalRecord{Op: journalPut, ID: user.ID, User: user}); err != nil {
+		repo.mem.remove(user.ID) // ย้อนกลับ เพื่อให้ข้อมูลในหน่วยความจำตรงกับไฟล์
+		return err
+	}
+	return nil
+}
+
+// Update ปรับปรุงข้อมูลผู้ใช้และบันทึกลง journal หากบันทึกไม่สำเร็จจะย้อนกลับเป็นข้อมูลเดิม
+func (repo *FileUserRepository) Update(user *domain.User) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.closed {
+		return os.ErrClosed
+	}
+	previous, err := repo.mem.GetByID(user.ID)
+	if err != nil {
+		return err
+	}
+	if err := repo.mem.Update(user); err != nil {
+		return err
+	}
+	if err := repo.appendLocked(journalRecord{Op: journalPut, ID: user.ID, User: user}); err != nil {
+		repo.mem.put(previous)
+		return err
+	}
+	return nil
+}
+
+// Rename เปลี่ยนชื่อผู้ใช้และบันทึกลง journal หากบันทึกไม่สำเร็จจะย้อนกลับเป็นชื่อเดิม
+func (repo *FileUserRepository) Rename(id int64, newUsername string) (*domain.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.closed {
+		return nil, os.ErrClosed
+	}
+	previous, err := repo.mem.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	renamed, err := repo.mem.Rename(id, newUsername)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.appendLocked(journalRecord{Op: journalPut, ID: id, User: renamed}); err != nil {
+		repo.mem.put(previous)
+		return nil, err
+	}
+	return renamed, nil
+}
+
+// Delete ลบผู้ใช้และบันทึกลง journal หากบันทึกไม่สำเร็จจะย้อนกลับการลบ
+func (repo *FileUserRepository) Delete(id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.closed {
+		return os.ErrClosed
+	}
+	previous, err := repo.mem.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := repo.mem.Delete(id); err != nil {
+		return err
+	}
+	if err := repo.appendLocked(journalRecord{Op: journalDelete, ID: id}); err != nil {
+		repo.mem.put(previous)
+		return err
+	}
+	return nil
+}
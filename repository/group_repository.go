@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Error messages
+var (
+	// สร้าง errors.New และกำหนดค่าข้อความให้ตัวแปร ErrGroupNotFound และ ErrGroupExists
+	ErrGroupNotFound = errors.New("group not found")
+	ErrGroupExists   = errors.New("group already exists")
+)
+
+// InMemoryGroupRepository เก็บกลุ่มของผู้ใช้ไว้ในหน่วยความจำ
+type InMemoryGroupRepository struct {
+	mu             sync.RWMutex            // ใช้เพื่อควบคุมการเข้าถึงข้อมูลกลุ่มอย่างปลอดภัยในหลายเธรด
+	groupIDCounter int64                   // เพิ่มตัวเลขเพื่อสร้าง ID ของกลุ่ม
+	groups         map[int64]*domain.Group // map สำหรับเก็บกลุ่มตามรหัสประจำตัว
+	names          map[string]int64        // map สำหรับค้นหารหัสกลุ่มตามชื่อกลุ่ม
+}
+
+// NewInMemoryGroupRepository สร้าง InMemoryGroupRepository ใหม่
+func NewInMemoryGroupRepository() *InMemoryGroupRepository {
+	return &InMemoryGroupRepository{
+		groups: make(map[int64]*domain.Group),
+		names:  make(map[string]int64),
+	}
+}
+
+// Create เพิ่มกลุ่มใหม่ลงใน repository และกำหนด ID ให้กลุ่ม
+func (repo *InMemoryGroupRepository) Create(group *domain.Group) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	if _, exists := repo.names[group.Name]; exists { // ตรวจสอบว่ามีกลุ่มชื่อนี้อยู่แล้วหรือไม่
+		return ErrGroupExists
+	}
+
+	repo.groupIDCounter++ // เพิ่มค่าตัวนับ ID ของกลุ่มใหม่
+	group.ID = repo.groupIDCounter
+	repo.groups[group.ID] = group
+	repo.names[group.Name] = group.ID
+	return nil
+}
+
+// GetByID ดึงกลุ่มตามรหัสประจำตัว
+func (repo *InMemoryGroupRepository) GetByID(id int64) (*domain.Group, error) {
+	repo.mu.RLock()         // ล็อกการอ่าน
+	defer repo.mu.RUnlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	group, exists := repo.groups[id]
+	if !exists { // ถ้าไม่พบกลุ่มให้คืนค่าข้อผิดพลาด
+		return nil, ErrGroupNotFound
+	}
+	return group, nil
+}
+
+// GetAll ดึงกลุ่มทั้งหมด เรียงตามรหัสประจำตัว
+func (repo *InMemoryGroupRepository) GetAll() ([]*domain.Group, error) {
+	repo.mu.RLock()         // ล็อกการอ่าน
+	defer repo.mu.RUnlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	groups := make([]*domain.Group, 0, len(repo.groups))
+	for _, group := range repo.groups {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, nil
+}
+
+// GetByMember ดึงกลุ่มทั้งหมดที่ผู้ใช้ userID เป็นสมาชิก
+func (repo *InMemoryGroupRepository) GetByMember(userID int64) ([]*domain.Group, error) {
+	repo.mu.RLock()         // ล็อกการอ่าน
+	defer repo.mu.RUnlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	groups := make([]*domain.Group, 0)
+	for _, group := range repo.groups {
+		if group.HasMember(userID) {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, nil
+}
+
+// Update ปรับปรุงข้อมูลกลุ่มที่มีอยู่ เช่น สมาชิก บทบาท และสิทธิ์
+func (repo *InMemoryGroupRepository) Update(group *domain.Group) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	previous, exists := repo.groups[group.ID]
+	if !exists { // ตรวจสอบว่ามีกลุ่มนี้อยู่หรือไม่
+		return ErrGroupNotFound
+	}
+	if id, taken := repo.names[group.Name]; taken && id != group.ID { // ชื่อใหม่ถูกใช้โดยกลุ่มอื่นแล้ว
+		return ErrGroupExists
+	}
+
+	delete(repo.names, previous.Name)
+	repo.groups[group.ID] = group
+	repo.names[group.Name] = group.ID
+	return nil
+}
+
+// Delete ลบกลุ่มตามรหัสประจำตัว
+func (repo *InMemoryGroupRepository) Delete(id int64) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	group, exists := repo.groups[id]
+	if !exists { // ตรวจสอบว่ามีกลุ่มนี้อยู่หรือไม่
+		return ErrGroupNotFound
+	}
+	delete(repo.groups, id)
+	delete(repo.names, group.Name)
+	return nil
+}
+
+// put เพิ่มหรือแทนที่กลุ่มโดยใช้ ID เดิม ใช้สำหรับโหลดข้อมูลและย้อนกลับการเปลี่ยนแปลง
+func (repo *InMemoryGroupRepository) put(group *domain.Group) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if previous, exists := repo.groups[group.ID]; exists {
+		delete(repo.names, previous.Name)
+	}
+	repo.groups[group.ID] = group
+	repo.names[group.Name] = group.ID
+	if group.ID > repo.groupIDCounter {
+		repo.groupIDCounter = group.ID
+	}
+}
+
+// snapshot คืนค่ากลุ่มทั้งหมดเรียงตาม ID พร้อมค่าตัวนับ ID ปัจจุบัน
+func (repo *InMemoryGroupRepository) snapshot() ([]*domain.Group, int64) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	groups := make([]*domain.Group, 0, len(repo.groups))
+	for _, group := range repo.groups {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, repo.groupIDCounter
+}
+
+// advanceCounter เลื่อนตัวนับ ID ให้ไม่น้อยกว่า counter เพื่อไม่ให้ ID ของกลุ่มที่ถูกลบถูกใช้ซ้ำ
+func (repo *InMemoryGroupRepository) advanceCounter(counter int64) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if counter > repo.groupIDCounter {
+		repo.groupIDCounter = counter
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"sync"
+	"time"
+)
+
+// pruneInterval จำนวนครั้งที่เรียก Take ก่อนจะลบ bucket ที่เต็มแล้วออกจากหน่วยความจำ
+const pruneInterval = 1024
+
+// tokenBucket สถานะของ bucket สำหรับผู้เรียกหนึ่งราย
+type tokenBucket struct {
+	tokens  float64          // จำนวน token ที่เหลืออยู่
+	updated time.Time        // เวลาที่คำนวณ token ล่าสุด
+	limit   domain.RateLimit // ขนาดและอัตราการเติมที่ใช้ตอนคำนวณล่าสุด
+}
+
+// InMemoryRateLimiter จำกัดอัตราการเรียกด้วย token bucket ที่เก็บไว้ในหน่วยความจำ
+type InMemoryRateLimiter struct {
+	mu      sync.Mutex              // ใช้เพื่อควบคุมการเข้าถึง buckets อย่างปลอดภัยในหลายเธรด
+	buckets map[string]*tokenBucket // map สำหรับเก็บ bucket ตามคีย์ของผู้เรียก
+	calls   int                     // จำนวนครั้งที่เรียก Take ตั้งแต่ล้างครั้งล่าสุด
+}
+
+// NewInMemoryRateLimiter สร้าง InMemoryRateLimiter ใหม่
+func NewInMemoryRateLimiter() *InMemoryRateLimiter {
+	return &InMemoryRateLimiter{
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Take ใช้ token หนึ่งอันจาก bucket ของคีย์ที่ระบุ และคืนค่าสถานะโควตา
+func (l *InMemoryRateLimiter) Take(key string, limit domain.RateLimit) (domain.RateLimitStatus, error) {
+	l.mu.Lock()         // ล็อกการเขียน
+	defer l.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	now := time.Now()
+	l.calls++
+	if l.calls >= pruneInterval { // ลบ bucket ที่เต็มแล้วเป็นระยะ เพื่อไม่ให้หน่วยความจำเพิ่มขึ้นเรื่อยๆ
+		l.prune(now)
+		l.calls = 0
+	}
+
+	bucket := l.refill(key, limit, now)
+	if bucket.tokens < 1 { // ไม่มี token เหลือ
+		return l.status(bucket, limit, false), nil
+	}
+	bucket.tokens-- // ใช้ token หนึ่งอัน
+	return l.status(bucket, limit, true), nil
+}
+
+// Peek คืนค่าสถานะโควตาของคีย์ที่ระบุโดยไม่ใช้ token
+func (l *InMemoryRateLimiter) Peek(key string, limit domain.RateLimit) (domain.RateLimitStatus, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket := l.refill(key, limit, time.Now())
+	return l.status(bucket, limit, bucket.tokens >= 1), nil
+}
+
+// refill เติม token ตามเวลาที่ผ่านไป และสร้าง bucket ใหม่หากยังไม่มี
+func (l *InMemoryRateLimiter) refill(key string, limit domain.RateLimit, now time.Time) *tokenBucket {
+	bucket, exists := l.buckets[key]
+	if !exists { // ผู้เรียกรายใหม่เริ่มด้วย bucket ที่เต็ม
+		bucket = &tokenBucket{tokens: float64(limit.Capacity), updated: now, limit: limit}
+		l.buckets[key] = bucket
+		return bucket
+	}
+
+	if limit.RefillEvery > 0 {
+		bucket.tokens += float64(now.Sub(bucket.updated)) / float64(limit.RefillEvery) // เติม token ตามเวลาที่ผ่านไป
+	}
+	if bucket.tokens > float64(limit.Capacity) {
+		bucket.tokens = float64(limit.Capacity) // ไม่เกินขนาดของ bucket
+	}
+	bucket.updated = now
+	bucket.limit = limit
+	return bucket
+}
+
+// status สร้างสถานะโควตาจาก bucket
+func (l *InMemoryRateLimiter) status(bucket *tokenBucket, limit domain.RateLimit, allowed bool) domain.RateLimitStatus {
+	status := domain.RateLimitStatus{
+		Allowed:   allowed,
+		Limit:     limit.Capacity,
+		Remaining: int(bucket.tokens),
+	}
+	if bucket.tokens < 1 && limit.RefillEvery > 0 { // คำนวณเวลาที่ต้องรอจนกว่าจะมี token หนึ่งอัน
+		status.RetryAfter = time.Duration((1 - bucket.tokens) * float64(limit.RefillEvery))
+	}
+	return status
+}
+
+// prune ลบ bucket ที่จะเต็มแล้ว ณ เวลาปัจจุบัน เพราะมีสถานะเหมือนผู้เรียกรายใหม่
+func (l *InMemoryRateLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.limit.RefillEvery <= 0 { // bucket ที่ไม่มีการเติมต้องเก็บไว้ตลอด
+			continue
+		}
+		tokens := bucket.tokens + float64(now.Sub(bucket.updated))/float64(bucket.limit.RefillEvery)
+		if tokens >= float64(bucket.limit.Capacity) {
+			delete(l.buckets, key)
+		}
+	}
+}
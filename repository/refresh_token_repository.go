@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"errors"
+	"sync"
+	"time"
+)
+
+// refreshTokenPruneInterval ระยะเวลาขั้นต่ำระหว่างการลบ refresh token ที่หมดอายุแล้วออกจากหน่วยความจำ
+const refreshTokenPruneInterval = time.Minute
+
+// Error messages
+var (
+	// สร้าง errors.New และกำหนดค่าข้อความให้ตัวแปร ErrRefreshTokenNotFound และ ErrRefreshTokenExists
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenExists   = errors.New("refresh token already exists")
+)
+
+// InMemoryRefreshTokenRepository เก็บสถานะของ refresh token ไว้ในหน่วยความจำ
+type InMemoryRefreshTokenRepository struct {
+	mu        sync.Mutex                      // ใช้เพื่อควบคุมการเข้าถึงข้อมูลอย่างปลอดภัยในหลายเธรด
+	tokens    map[string]*domain.RefreshToken // map สำหรับเก็บ refresh token ตามรหัสโทเคน
+	nextPrune time.Time                       // เวลาที่จะลบโทเคนที่หมดอายุครั้งถัดไป
+}
+
+// NewInMemoryRefreshTokenRepository สร้าง InMemoryRefreshTokenRepository ใหม่
+func NewInMemoryRefreshTokenRepository() *InMemoryRefreshTokenRepository {
+	return &InMemoryRefreshTokenRepository{
+		tokens: make(map[string]*domain.RefreshToken),
+	}
+}
+
+// Create เพิ่ม refresh token ใหม่ลงใน repository
+func (repo *InMemoryRefreshTokenRepository) Create(token *domain.RefreshToken) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	now := time.Now()
+	if now.After(repo.nextPrune) { // ลบโทเคนที่หมดอายุเป็นระยะ เพื่อไม่ให้ map โตขึ้นเรื่อยๆ
+		repo.pruneExpired(now)
+		repo.nextPrune = now.Add(refreshTokenPruneInterval)
+	}
+
+	if _, exists := repo.tokens[token.ID]; exists { // ตรวจสอบว่ามีโทเคนนี้อยู่แล้วหรือไม่
+		return ErrRefreshTokenExists
+	}
+	stored := *token // เก็บสำเนา เพื่อไม่ให้ผู้เรียกแก้ไขข้อมูลภายในได้โดยตรง
+	repo.tokens[token.ID] = &stored
+	return nil
+}
+
+// pruneExpired ลบโทเคนที่หมดอายุก่อนเวลา now ผู้เรียกต้องถือ mu ไว้
+// โทเคนที่หมดอายุแล้วใช้งานไม่ได้อยู่แล้วจากการตรวจสอบ exp ใน JWT จึงไม่จำเป็นต้องเก็บไว้ตรวจจับการใช้ซ้ำ
+func (repo *InMemoryRefreshTokenRepository) pruneExpired(now time.Time) {
+	for id, token := range repo.tokens {
+		if !token.ExpiresAt.IsZero() && token.ExpiresAt.Before(now) {
+			delete(repo.tokens, id)
+		}
+	}
+}
+
+// Consume ทำเครื่องหมายว่า refresh token ถูกใช้แล้ว โดยคืนค่า firstUse เป็น false หากโทเคนเคยถูกใช้มาก่อน
+func (repo *InMemoryRefreshTokenRepository) Consume(id string) (*domain.RefreshToken, bool, error) {
+	repo.mu.Lock()         // ล็อกการเขียน เพื่อให้การตรวจสอบและการทำเครื่องหมายเกิดขึ้นพร้อมกัน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	token, exists := repo.tokens[id]
+	if !exists { // ถ้าไม่พบโทเคนให้คืนค่าข้อผิดพลาด
+		return nil, false, ErrRefreshTokenNotFound
+	}
+
+	firstUse := !token.Used // ตรวจสอบว่าเป็นการใช้ครั้งแรกหรือไม่
+	token.Used = true       // ทำเครื่องหมายว่าโทเคนถูกใช้แล้ว
+	consumed := *token      // คืนค่าสำเนา เพราะ RevokeFamily และ RevokeByUserID อาจแก้ไขโทเคนนี้หลังปลดล็อก
+	return &consumed, firstUse, nil
+}
+
+// RevokeFamily ยกเลิก refresh token ทั้งหมดที่อยู่ในกลุ่มเดียวกัน
+func (repo *InMemoryRefreshTokenRepository) RevokeFamily(family string) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	for _, token := range repo.tokens {
+		if token.Family == family {
+			token.Revoked = true // ยกเลิกโทเคนในกลุ่มเดียวกัน
+		}
+	}
+	return nil
+}
+
+// RevokeByUserID ยกเลิก refresh token ทั้งหมดของผู้ใช้
+func (repo *InMemoryRefreshTokenRepository) RevokeByUserID(userID int64) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	for _, token := range repo.tokens {
+		if token.UserID == userID {
+			token.Revoked = true // ยกเลิกโทเคนของผู้ใช้
+		}
+	}
+	return nil
+}
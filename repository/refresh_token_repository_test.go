@@ -0,0 +1,53 @@
+package repository_test
+
+import (
+	"Basic_login/domain"
+	"Basic_login/repository"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenConsumeReturnsCopy(t *testing.T) {
+	repo := repository.NewInMemoryRefreshTokenRepository()
+	token := &domain.RefreshToken{ID: "a", Family: "f", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.Create(token); err != nil {
+		t.Fatalf("Create error = %v", err)
+	}
+
+	consumed, firstUse, err := repo.Consume("a")
+	if err != nil || !firstUse {
+		t.Fatalf("Consume = %v, %v, want first use", firstUse, err)
+	}
+	if err := repo.RevokeByUserID(1); err != nil {
+		t.Fatalf("RevokeByUserID error = %v", err)
+	}
+	if consumed.Revoked || token.Revoked {
+		t.Error("revocation changed a token returned to or passed in by the caller")
+	}
+
+	again, firstUse, err := repo.Consume("a")
+	if err != nil {
+		t.Fatalf("Consume error = %v", err)
+	}
+	if firstUse || !again.Revoked {
+		t.Errorf("second Consume = %+v, firstUse %v, want used and revoked", again, firstUse)
+	}
+}
+
+func TestRefreshTokenPrunesExpired(t *testing.T) {
+	repo := repository.NewInMemoryRefreshTokenRepository()
+	if err := repo.Create(&domain.RefreshToken{ID: "expired", ExpiresAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatalf("Create error = %v", err)
+	}
+	repo.PruneNow()
+	if err := repo.Create(&domain.RefreshToken{ID: "live", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Create error = %v", err)
+	}
+
+	if _, _, err := repo.Consume("expired"); err != repository.ErrRefreshTokenNotFound {
+		t.Errorf("Consume(expired) error = %v, want ErrRefreshTokenNotFound", err)
+	}
+	if _, _, err := repo.Consume("live"); err != nil {
+		t.Errorf("Consume(live) error = %v", err)
+	}
+}
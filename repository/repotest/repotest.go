@@ -0,0 +1,493 @@
+// Package repotest มีชุดทดสอบพฤติกรรมที่ทุก implementation ของ usecase.UserRepository ต้องผ่าน
+// เพื่อให้เปลี่ยนที่เก็บข้อมูลได้โดยที่ use case ทำงานเหมือนเดิม
+//
+// ตัวอย่างการใช้งานในไฟล์ทดสอบของ implementation:
+//
+//	func TestMyUserRepository(t *testing.T) {
+//		repotest.TestUserRepository(t, func(t *testing.T) usecase.UserRepository {
+//			return NewMyUserRepository(...)
+//		})
+//	}
+package repotest
+
+import (
+	"Basic_login/domain"
+	"Basic_login/repository"
+	"Basic_login/usecase"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// concurrency จำนวน goroutine ที่ใช้ในการทดสอบการทำงานพร้อมกัน
+const concurrency = 32
+
+// Factory สร้าง repository ว่างใหม่สำหรับแต่ละการทดสอบย่อย การปิดหรือลบข้อมูลให้ลงทะเบียนด้วย t.Cleanup
+type Factory func(t *testing.T) usecase.UserRepository
+
+// TestUserRepository ทดสอบว่า repository ที่สร้างจาก newRepo มีพฤติกรรมตรงกับ InMemoryUserRepository
+// ข้อผิดพลาดต้องตรวจสอบได้ด้วย errors.Is กับ repository.ErrUserNotFound, ErrUserExists, ErrEmailExists และ ErrUsernameMismatch
+func TestUserRepository(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo usecase.UserRepository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"DuplicateUsername", testDuplicateUsername},
+		{"DuplicateEmail", testDuplicateEmail},
+		{"NotFound", testNotFound},
+		{"Update", testUpdate},
+		{"UpdateConflicts", testUpdateConflicts},
+		{"Rename", testRename},
+		{"Delete", testDelete},
+		{"IDMonotonicity", testIDMonotonicity},
+		{"ConcurrentCreateSameUsername", testConcurrentCreateSameUsername},
+		{"ConcurrentCreateDistinct", testConcurrentCreateDistinct},
+		{"GetAllSnapshotIsolation", testGetAllSnapshotIsolation},
+		{"ReturnedUserIsolation", testReturnedUserIsolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepo(t))
+		})
+	}
+}
+
+// mustCreate สร้างผู้ใช้และหยุดการทดสอบหากไม่สำเร็จ
+func mustCreate(t *testing.T, repo usecase.UserRepository, username, email string) *domain.User {
+	t.Helper()
+	user := &domain.User{Username: username, Email: email, Role: "user", Password: "hash-" + username}
+	if err := repo.Create(user); err != nil {
+		t.Fatalf("Create(%q) error = %v", username, err)
+	}
+	return user
+}
+
+// wantErr ตรวจสอบว่า err ตรงกับ target ตาม errors.Is
+func wantErr(t *testing.T, op string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("%s error = %v, want %v", op, err, target)
+	}
+}
+
+// wantUser ตรวจสอบฟิลด์หลักของผู้ใช้ที่ดึงมา
+func wantUser(t *testing.T, op string, got *domain.User, err error, want *domain.User) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s error = %v", op, err)
+	}
+	if got.ID != want.ID || got.Username != want.Username || got.Email != want.Email ||
+		got.Role != want.Role || got.Password != want.Password {
+		t.Fatalf("%s = {ID:%d Username:%q Email:%q Role:%q Password:%q}, want {ID:%d Username:%q Email:%q Role:%q Password:%q}",
+			op, got.ID, got.Username, got.Email, got.Role, got.Password,
+			want.ID, want.Username, want.Email, want.Role, want.Password)
+	}
+}
+
+func testCreateAndGet(t *testing.T, repo usecase.UserRepository) {
+	alice := mustCreate(t, repo, "alice", "alice@example.com")
+	if alice.ID == 0 {
+		t.Fatal("Create did not assign an ID")
+	}
+
+	got, err := repo.GetByID(alice.ID)
+	wantUser(t, "GetByID", got, err, alice)
+	got, err = repo.GetByUsername("alice")
+	wantUser(t, "GetByUsername", got, err, alice)
+	got, err = repo.GetByEmail("alice@example.com")
+	wantUser(t, "GetByEmail", got, err, alice)
+
+	bob := mustCreate(t, repo, "bob", "")
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("GetAll returned %d users, want 2", len(all))
+	}
+	seen := map[int64]bool{}
+	for _, user := range all {
+		seen[user.ID] = true
+	}
+	if !seen[alice.ID] || !seen[bob.ID] {
+		t.Fatalf("GetAll = %v, want users %d and %d", all, alice.ID, bob.ID)
+	}
+}
+
+func testDuplicateUsername(t *testing.T, repo usecase.UserRepository) {
+	alice := mustCreate(t, repo, "alice", "")
+	err := repo.Create(&domain.User{Username: "alice"})
+	wantErr(t, "Create duplicate username", err, repository.ErrUserExists)
+
+	got, err := repo.GetByUsername("alice")
+	wantUser(t, "GetByUsername after duplicate", got, err, alice) // ผู้ใช้เดิมต้องไม่ถูกแทนที่
+	all, _ := repo.GetAll()
+	if len(all) != 1 {
+		t.Fatalf("GetAll returned %d users after rejected duplicate, want 1", len(all))
+	}
+}
+
+func testDuplicateEmail(t *testing.T, repo usecase.UserRepository) {
+	mustCreate(t, repo, "alice", "shared@example.com")
+	err := repo.Create(&domain.User{Username: "bob", Email: "shared@example.com"})
+	wantErr(t, "Create duplicate email", err, repository.ErrEmailExists)
+	if _, err := repo.GetByUsername("bob"); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Fatalf("rejected user was stored: GetByUsername error = %v", err)
+	}
+
+	// ผู้ใช้ที่ไม่มีอีเมลไม่ถือว่าอีเมลซ้ำกัน
+	mustCreate(t, repo, "carol", "")
+	mustCreate(t, repo, "dave", "")
+}
+
+func testNotFound(t *testing.T, repo usecase.UserRepository) {
+	_, err := repo.GetByID(42)
+	wantErr(t, "GetByID", err, repository.ErrUserNotFound)
+	_, err = repo.GetByUsername("nobody")
+	wantErr(t, "GetByUsername", err, repository.ErrUserNotFound)
+	_, err = repo.GetByEmail("nobody@example.com")
+	wantErr(t, "GetByEmail", err, repository.ErrUserNotFound)
+	_, err = repo.GetByEmail("")
+	wantErr(t, "GetByEmail empty", err, repository.ErrUserNotFound)
+	err = repo.Update(&domain.User{ID: 42, Username: "nobody"})
+	wantErr(t, "Update", err, repository.ErrUserNotFound)
+	_, err = repo.Rename(42, "somebody")
+	wantErr(t, "Rename", err, repository.ErrUserNotFound)
+	err = repo.Delete(42)
+	wantErr(t, "Delete", err, repository.ErrUserNotFound)
+
+	all, err := repo.GetAll()
+	if err != nil || len(all) != 0 {
+		t.Fatalf("GetAll on empty repository = %v, %v; want no users", all, err)
+	}
+}
+
+func testUpdate(t *testing.T, repo usecase.UserRepository) {
+	alice := mustCreate(t, repo, "alice", "old@example.com")
+
+	updated := *alice
+	updated.Role = "admin"
+	updated.Email = "new@example.com"
+	updated.Status = domain.StatusDisabled
+	if err := repo.Update(&updated); err != nil {
+		t.Fatalf("Update error = %v", err)
+	}
+
+	got, err := repo.GetByID(alice.ID)
+	wantUser(t, "GetByID after Update", got, err, &updated)
+	if got.Status != domain.StatusDisabled {
+		t.Fatalf("Status = %q, want %q", got.Status, domain.StatusDisabled)
+	}
+	got, err = repo.GetByEmail("new@example.com")
+	wantUser(t, "GetByEmail new address", got, err, &updated)
+	_, err = repo.GetByEmail("old@example.com")
+	wantErr(t, "GetByEmail old address", err, repository.ErrUserNotFound)
+
+	// ลบอีเมลออก โดยใช้สำเนาใหม่ เพราะ repository อาจเก็บ pointer ที่ส่งให้ Update ไว้โดยตรง
+	cleared := updated
+	cleared.Email = ""
+	if err := repo.Update(&cleared); err != nil {
+		t.Fatalf("Update clearing email error = %v", err)
+	}
+	_, err = repo.GetByEmail("new@example.com")
+	wantErr(t, "GetByEmail cleared address", err, repository.ErrUserNotFound)
+}
+
+func testUpdateConflicts(t *testing.T, repo usecase.UserRepository) {
+	alice := mustCreate(t, repo, "alice", "alice@example.com")
+	bob := mustCreate(t, repo, "bob", "bob@example.com")
+
+	updated := *bob
+	updated.Email = "alice@example.com"
+	err := repo.Update(&updated)
+	wantErr(t, "Update to taken email", err, repository.ErrEmailExists)
+
+	updated = *bob
+	updated.Username = "robert"
+	err = repo.Update(&updated)
+	wantErr(t, "Update changing username", err, repository.ErrUsernameMismatch)
+
+	// ข้อมูลทั้งสองต้องไม่ถูกแก้ไขเมื่อ Update ล้มเหลว
+	got, err := repo.GetByID(bob.ID)
+	wantUser(t, "GetByID bob", got, err, bob)
+	got, err = repo.GetByEmail("alice@example.com")
+	wantUser(t, "GetByEmail alice", got, err, alice)
+}
+
+func testRename(t *testing.T, repo usecase.UserRepository) {
+	alice := mustCreate(t, repo, "alice", "alice@example.com")
+	mustCreate(t, repo, "bob", "")
+
+	_, err := repo.Rename(alice.ID, "bob")
+	wantErr(t, "Rename to taken username", err, repository.ErrUserExists)
+
+	renamed, err := repo.Rename(alice.ID, "alicia")
+	if err != nil {
+		t.Fatalf("Rename error = %v", err)
+	}
+	if renamed.Username != "alicia" || renamed.ID != alice.ID {
+		t.Fatalf("Rename returned {ID:%d Username:%q}, want {ID:%d Username:%q}", renamed.ID, renamed.Username, alice.ID, "alicia")
+	}
+	if len(renamed.PreviousUsernames) != 1 || renamed.PreviousUsernames[0].Username != "alice" {
+		t.Fatalf("PreviousUsernames = %v, want [alice]", renamed.PreviousUsernames)
+	}
+
+	_, err = repo.GetByUsername("alice")
+	wantErr(t, "GetByUsername old name", err, repository.ErrUserNotFound)
+	for _, get := range []func() (*domain.User, error){
+		func() (*domain.User, error) { return repo.GetByUsername("alicia") },
+		func() (*domain.User, error) { return repo.GetByID(alice.ID) },
+		func() (*domain.User, error) { return repo.GetByEmail("alice@example.com") },
+	} {
+		got, err := get()
+		if err != nil || got.Username != "alicia" {
+			t.Fatalf("lookup after Rename = %v, %v; want alicia", got, err)
+		}
+	}
+
+	// ชื่อเดิมว่างแล้ว จึงสร้างผู้ใช้ใหม่ด้วยชื่อนี้ได้
+	mustCreate(t, repo, "alice", "")
+}
+
+func testDelete(t *testing.T, repo usecase.UserRepository) {
+	alice := mustCreate(t, repo, "alice", "alice@example.com")
+	bob := mustCreate(t, repo, "bob", "")
+
+	if err := repo.Delete(alice.ID); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	_, err := repo.GetByID(alice.ID)
+	wantErr(t, "GetByID deleted", err, repository.ErrUserNotFound)
+	_, err = repo.GetByUsername("alice")
+	wantErr(t, "GetByUsername deleted", err, repository.ErrUserNotFound)
+	_, err = repo.GetByEmail("alice@example.com")
+	wantErr(t, "GetByEmail deleted", err, repository.ErrUserNotFound)
+	err = repo.Delete(alice.ID)
+	wantErr(t, "Delete twice", err, repository.ErrUserNotFound)
+
+	got, err := repo.GetByID(bob.ID)
+	wantUser(t, "GetByID other user", got, err, bob)
+
+	// ชื่อผู้ใช้และอีเมลของผู้ใช้ที่ถูกลบนำกลับมาใช้ได้
+	mustCreate(t, repo, "alice", "alice@example.com")
+}
+
+func testIDMonotonicity(t *testing.T, repo usecase.UserRepository) {
+	var last int64
+	for i := 0; i < 5; i++ {
+		user := mustCreate(t, repo, fmt.Sprintf("user%d", i), "")
+		if user.ID <= last {
+			t.Fatalf("Create assigned ID %d after %d, want increasing IDs", user.ID, last)
+		}
+		last = user.ID
+	}
+
+	// ID ของผู้ใช้ที่ถูกลบต้องไม่ถูกใช้ซ้ำ แม้จะเป็นผู้ใช้ล่าสุด
+	if err := repo.Delete(last); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	user := mustCreate(t, repo, "after-delete", "")
+	if user.ID <= last {
+		t.Fatalf("Create after Delete assigned ID %d, want greater than %d", user.ID, last)
+	}
+
+	// Create ที่ล้มเหลวต้องไม่เปลี่ยน ID ของผู้ใช้ที่ส่งมา
+	duplicate := &domain.User{Username: "after-delete"}
+	if err := repo.Create(duplicate); err == nil {
+		t.Fatal("Create duplicate succeeded")
+	}
+	if duplicate.ID != 0 {
+		t.Fatalf("failed Create assigned ID %d", duplicate.ID)
+	}
+}
+
+func testConcurrentCreateSameUsername(t *testing.T, repo usecase.UserRepository) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		successes int
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.Create(&domain.User{Username: "racer"})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				successes++
+			case !errors.Is(err, repository.ErrUserExists):
+				t.Errorf("concurrent Create error = %v, want nil or %v", err, repository.ErrUserExists)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes != 1 {
+		t.Fatalf("%d concurrent Creates of the same username succeeded, want exactly 1", successes)
+	}
+	all, _ := repo.GetAll()
+	if len(all) != 1 {
+		t.Fatalf("GetAll returned %d users, want 1", len(all))
+	}
+}
+
+func testConcurrentCreateDistinct(t *testing.T, repo usecase.UserRepository) {
+	users := make([]*domain.User, concurrency)
+	var wg sync.WaitGroup
+	for i := range users {
+		users[i] = &domain.User{Username: fmt.Sprintf("user%d", i)}
+		wg.Add(1)
+		go func(user *domain.User) {
+			defer wg.Done()
+			if err := repo.Create(user); err != nil {
+				t.Errorf("concurrent Create(%q) error = %v", user.Username, err)
+			}
+		}(users[i])
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	ids := map[int64]string{}
+	for _, user := range users {
+		if other, taken := ids[user.ID]; taken {
+			t.Fatalf("users %q and %q were both assigned ID %d", other, user.Username, user.ID)
+		}
+		ids[user.ID] = user.Username
+		got, err := repo.GetByID(user.ID)
+		if err != nil || got.Username != user.Username {
+			t.Fatalf("GetByID(%d) = %v, %v; want %q", user.ID, got, err, user.Username)
+		}
+	}
+}
+
+func testGetAllSnapshotIsolation(t *testing.T, repo usecase.UserRepository) {
+	alice := mustCreate(t, repo, "alice", "alice@example.com")
+	bob := mustCreate(t, repo, "bob", "")
+
+	snapshot, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll error = %v", err)
+	}
+	before := make(map[int64]domain.User, len(snapshot))
+	for _, user := range snapshot {
+		before[user.ID] = *user
+	}
+
+	// เปลี่ยนแปลงข้อมูลหลังจากได้ snapshot แล้ว
+	mustCreate(t, repo, "carol", "")
+	if err := repo.Delete(bob.ID); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	updated := *alice
+	updated.Role = "admin"
+	if err := repo.Update(&updated); err != nil {
+		t.Fatalf("Update error = %v", err)
+	}
+	if _, err := repo.Rename(alice.ID, "alicia"); err != nil {
+		t.Fatalf("Rename error = %v", err)
+	}
+
+	// slice และผู้ใช้ที่คืนค่าไปแล้วต้องไม่เปลี่ยนตาม
+	if len(snapshot) != len(before) {
+		t.Fatalf("snapshot length changed from %d to %d", len(before), len(snapshot))
+	}
+	for _, user := range snapshot {
+		want, ok := before[user.ID]
+		if !ok {
+			t.Fatalf("snapshot now contains user %d that was not there before", user.ID)
+		}
+		if user.Username != want.Username || user.Role != want.Role || user.Email != want.Email {
+			t.Fatalf("snapshot user %d changed to {Username:%q Role:%q}, want {Username:%q Role:%q}",
+				user.ID, user.Username, user.Role, want.Username, want.Role)
+		}
+	}
+
+	// การแก้ไข slice ที่ได้มาต้องไม่กระทบข้อมูลใน repository
+	snapshot[0] = nil
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("GetAll returned %d users, want 2", len(all))
+	}
+	for _, user := range all {
+		if user == nil {
+			t.Fatal("GetAll returned a nil user after caller modified an earlier snapshot")
+		}
+	}
+}
+
+func testReturnedUserIsolation(t *testing.T, repo usecase.UserRepository) {
+	alice := mustCreate(t, repo, "alice", "alice@example.com")
+	want := *alice
+	want.RecoveryCodes = []string{"code-1"}
+	if err := repo.Update(&want); err != nil {
+		t.Fatalf("Update error = %v", err)
+	}
+
+	// mutate เปลี่ยนแปลงผู้ใช้ที่ได้มาโดยไม่ผ่าน Update
+	mutate := func(user *domain.User) {
+		user.Role = "admin"
+		user.Email = "mallory@example.com"
+		user.Password = "hash-mallory"
+		if len(user.RecoveryCodes) > 0 {
+			user.RecoveryCodes[0] = "code-mallory"
+		}
+	}
+	// check ตรวจสอบว่าข้อมูลใน repository ยังเหมือนเดิม
+	check := func(op string) {
+		t.Helper()
+		got, err := repo.GetByID(alice.ID)
+		wantUser(t, op, got, err, &want)
+		if len(got.RecoveryCodes) != 1 || got.RecoveryCodes[0] != "code-1" {
+			t.Fatalf("after %s RecoveryCodes = %q, want [code-1]", op, got.RecoveryCodes)
+		}
+		if _, err := repo.GetByEmail("mallory@example.com"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("after %s GetByEmail(mallory) error = %v, want ErrUserNotFound", op, err)
+		}
+	}
+
+	// ผู้ใช้ที่ส่งให้ Create และ Update ต้องไม่ถูกเก็บไว้โดยตรง
+	mutate(alice)
+	check("mutating the created user")
+	updated := want
+	updated.RecoveryCodes = []string{"code-1"}
+	if err := repo.Update(&updated); err != nil {
+		t.Fatalf("Update error = %v", err)
+	}
+	mutate(&updated)
+	check("mutating the updated user")
+
+	// ผู้ใช้ที่คืนค่าจากการอ่านทุกแบบต้องเป็นสำเนา
+	got, err := repo.GetByID(alice.ID)
+	if err != nil {
+		t.Fatalf("GetByID error = %v", err)
+	}
+	mutate(got)
+	check("mutating GetByID")
+	if got, err = repo.GetByUsername("alice"); err != nil {
+		t.Fatalf("GetByUsername error = %v", err)
+	}
+	mutate(got)
+	check("mutating GetByUsername")
+	if got, err = repo.GetByEmail("alice@example.com"); err != nil {
+		t.Fatalf("GetByEmail error = %v", err)
+	}
+	mutate(got)
+	check("mutating GetByEmail")
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll error = %v", err)
+	}
+	for _, user := range all {
+		mutate(user)
+	}
+	check("mutating GetAll")
+}
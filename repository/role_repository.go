@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Error messages
+var (
+	// สร้าง errors.New และกำหนดค่าข้อความให้ตัวแปร ErrRoleNotFound และ ErrRoleExists
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleExists   = errors.New("role already exists")
+)
+
+// InMemoryRoleRepository เก็บบทบาทและชุดสิทธิ์ไว้ในหน่วยความจำ
+type InMemoryRoleRepository struct {
+	mu    sync.RWMutex            // ใช้เพื่อควบคุมการเข้าถึงข้อมูลบทบาทอย่างปลอดภัยในหลายเธรด
+	roles map[string]*domain.Role // map สำหรับเก็บบทบาทตามชื่อบทบาท
+}
+
+// NewInMemoryRoleRepository สร้าง InMemoryRoleRepository ใหม่
+func NewInMemoryRoleRepository() *InMemoryRoleRepository {
+	return &InMemoryRoleRepository{
+		roles: make(map[string]*domain.Role),
+	}
+}
+
+// Create เพิ่มบทบาทใหม่ลงใน repository
+func (repo *InMemoryRoleRepository) Create(role *domain.Role) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	if _, exists := repo.roles[role.Name]; exists { // ตรวจสอบว่ามีบทบาทนี้อยู่แล้วหรือไม่
+		return ErrRoleExists
+	}
+	repo.roles[role.Name] = role
+	return nil
+}
+
+// GetByName ดึงบทบาทตามชื่อบทบาท
+func (repo *InMemoryRoleRepository) GetByName(name string) (*domain.Role, error) {
+	repo.mu.RLock()         // ล็อกการอ่าน
+	defer repo.mu.RUnlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	role, exists := repo.roles[name]
+	if !exists { // ถ้าไม่พบบทบาทให้คืนค่าข้อผิดพลาด
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// GetAll ดึงบทบาททั้งหมด เรียงตามชื่อบทบาท
+func (repo *InMemoryRoleRepository) GetAll() ([]*domain.Role, error) {
+	repo.mu.RLock()         // ล็อกการอ่าน
+	defer repo.mu.RUnlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	roles := make([]*domain.Role, 0, len(repo.roles))
+	for _, role := range repo.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+// Update ปรับปรุงชุดสิทธิ์และการสืบทอดของบทบาทที่มีอยู่
+func (repo *InMemoryRoleRepository) Update(role *domain.Role) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	if _, exists := repo.roles[role.Name]; !exists { // ตรวจสอบว่ามีบทบาทนี้อยู่หรือไม่
+		return ErrRoleNotFound
+	}
+	repo.roles[role.Name] = role
+	return nil
+}
+
+// Delete ลบบทบาทตามชื่อบทบาท
+func (repo *InMemoryRoleRepository) Delete(name string) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	if _, exists := repo.roles[name]; !exists { // ตรวจสอบว่ามีบทบาทนี้อยู่หรือไม่
+		return ErrRoleNotFound
+	}
+	delete(repo.roles, name)
+	return nil
+}
+
+// put เพิ่มหรือแทนที่บทบาท ใช้สำหรับโหลดข้อมูลและย้อนกลับการเปลี่ยนแปลง
+func (repo *InMemoryRoleRepository) put(role *domain.Role) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.roles[role.Name] = role
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"errors"
+	"sync"
+)
+
+// Error messages
+var (
+	// สร้าง errors.New และกำหนดค่าข้อความให้ตัวแปร ErrSessionNotFound และ ErrSessionExists
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionExists   = errors.New("session already exists")
+)
+
+// InMemorySessionRepository เก็บเซสชันของผู้ใช้ไว้ในหน่วยความจำ
+type InMemorySessionRepository struct {
+	mu       sync.RWMutex                         // ใช้เพื่อควบคุมการเข้าถึงข้อมูลเซสชันอย่างปลอดภัยในหลายเธรด
+	sessions map[string]*domain.Session           // map สำหรับเก็บเซสชันตามรหัสเซสชัน
+	byUser   map[int64]map[string]*domain.Session // map สำหรับเก็บเซสชันแยกตามรหัสประจำตัวผู้ใช้
+}
+
+// NewInMemorySessionRepository สร้าง InMemorySessionRepository ใหม่
+func NewInMemorySessionRepository() *InMemorySessionRepository {
+	return &InMemorySessionRepository{
+		sessions: make(map[string]*domain.Session),
+		byUser:   make(map[int64]map[string]*domain.Session),
+	}
+}
+
+// Create เพิ่มเซสชันใหม่ลงใน repository
+func (repo *InMemorySessionRepository) Create(session *domain.Session) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	if _, exists := repo.sessions[session.ID]; exists { // ตรวจสอบว่ามีเซสชันนี้อยู่แล้วหรือไม่
+		return ErrSessionExists
+	}
+
+	repo.sessions[session.ID] = session // เพิ่มเซสชันใหม่ลงใน sessions
+	userSessions, exists := repo.byUser[session.UserID]
+	if !exists { // สร้าง map ของผู้ใช้หากยังไม่มี
+		userSessions = make(map[string]*domain.Session)
+		repo.byUser[session.UserID] = userSessions
+	}
+	userSessions[session.ID] = session // เพิ่มเซสชันลงในรายการของผู้ใช้
+	return nil
+}
+
+// GetByID ดึงเซสชันตามรหัสเซสชัน
+func (repo *InMemorySessionRepository) GetByID(id string) (*domain.Session, error) {
+	repo.mu.RLock()         // ล็อกการอ่าน
+	defer repo.mu.RUnlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	session, exists := repo.sessions[id]
+	if !exists { // ถ้าไม่พบเซสชันให้คืนค่าข้อผิดพลาด
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// GetByUserID ดึงเซสชันทั้งหมดของผู้ใช้
+func (repo *InMemorySessionRepository) GetByUserID(userID int64) ([]*domain.Session, error) {
+	repo.mu.RLock()         // ล็อกการอ่าน
+	defer repo.mu.RUnlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	sessions := make([]*domain.Session, 0, len(repo.byUser[userID])) // สร้าง slice สำหรับเก็บเซสชันของผู้ใช้
+	for _, session := range repo.byUser[userID] {
+		sessions = append(sessions, session) // เพิ่มเซสชันแต่ละรายการลงใน slice
+	}
+	return sessions, nil
+}
+
+// Update ปรับปรุงข้อมูลเซสชันที่มีอยู่
+func (repo *InMemorySessionRepository) Update(session *domain.Session) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	if _, exists := repo.sessions[session.ID]; !exists { // ตรวจสอบว่ามีเซสชันอยู่หรือไม่
+		return ErrSessionNotFound
+	}
+
+	repo.sessions[session.ID] = session               // อัปเดตเซสชันใน sessions
+	repo.byUser[session.UserID][session.ID] = session // อัปเดตเซสชันในรายการของผู้ใช้
+	return nil
+}
+
+// Delete ลบเซสชันตามรหัสเซสชัน
+func (repo *InMemorySessionRepository) Delete(id string) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	session, exists := repo.sessions[id]
+	if !exists { // ถ้าไม่พบเซสชันให้คืนค่าข้อผิดพลาด
+		return ErrSessionNotFound
+	}
+
+	delete(repo.sessions, id) // ลบเซสชันออกจาก sessions
+	delete(repo.byUser[session.UserID], id)
+	if len(repo.byUser[session.UserID]) == 0 { // ลบ map ของผู้ใช้เมื่อไม่มีเซสชันเหลืออยู่
+		delete(repo.byUser, session.UserID)
+	}
+	return nil
+}
+
+// DeleteByUserID ลบเซสชันทั้งหมดของผู้ใช้
+func (repo *InMemorySessionRepository) DeleteByUserID(userID int64) error {
+	repo.mu.Lock()         // ล็อกการเขียน
+	defer repo.mu.Unlock() // ปลดล็อกเมื่อฟังก์ชันสิ้นสุด
+
+	for id := range repo.byUser[userID] {
+		delete(repo.sessions, id) // ลบเซสชันแต่ละรายการออกจาก sessions
+	}
+	delete(repo.byUser, userID) // ลบรายการเซสชันของผู้ใช้
+	return nil
+}
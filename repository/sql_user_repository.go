@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"Basic_login/domain"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	_ "modernc.org/sqlite" // ไดรเวอร์ SQLite ที่เขียนด้วย Go ล้วน ไม่ต้องใช้ cgo
+)
+
+// userMigrations ขั้นตอนการปรับ schema ของตารางผู้ใช้ เรียงตามเวอร์ชัน (ลำดับที่ 0 คือเวอร์ชัน 1)
+// ห้ามแก้ไขหรือลบขั้นตอนที่มีอยู่แล้ว ให้เพิ่มขั้นตอนใหม่ต่อท้ายเท่านั้น
+var userMigrations = []string{
+	// ข้อมูลผู้ใช้ทั้งหมดเก็บเป็น JSON ใน data ส่วน username และ email แยกเป็นคอลัมน์เพื่อใช้เป็นดัชนี
+	// AUTOINCREMENT ทำให้ ID ของผู้ใช้ที่ถูกลบไม่ถูกใช้ซ้ำ เหมือนกับ InMemoryUserRepository
+	`CREATE TABLE users (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL,
+		email    TEXT NOT NULL DEFAULT '',
+		data     TEXT NOT NULL
+	)`,
+	`CREATE UNIQUE INDEX users_username ON users (username)`,
+	`CREATE UNIQUE INDEX users_email ON users (email) WHERE email <> ''`,
+}
+
+// SQLUserRepository เก็บผู้ใช้ในฐานข้อมูลผ่าน database/sql โดยทุกการเปลี่ยนแปลงทำภายใน transaction
+// เขียนสำหรับ SQLite (ใช้ placeholder แบบ ? และ partial index)
+type SQLUserRepository struct {
+	db *sql.DB // การเชื่อมต่อฐานข้อมูล
+}
+
+// NewSQLUserRepository สร้าง SQLUserRepository จากการเชื่อมต่อที่เปิดไว้แล้ว และปรับ schema ให้เป็นเวอร์ชันล่าสุด
+// การตรวจสอบชื่อผู้ใช้และอีเมลซ้ำทำภายใน transaction จึงควรจำกัด db ให้มีการเชื่อมต่อเดียว (db.SetMaxOpenConns(1))
+func NewSQLUserRepository(db *sql.DB) (*SQLUserRepository, error) {
+	if err := migrate(db, userMigrations); err != nil {
+		return nil, err
+	}
+	return &SQLUserRepository{db: db}, nil
+}
+
+// OpenSQLiteUserRepository เปิดหรือสร้างไฟล์ฐานข้อมูล SQLite ที่ path แล้วสร้าง SQLUserRepository
+func OpenSQLiteUserRepository(path string) (*SQLUserRepository, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1) // SQLite เขียนได้ทีละรายการ และทำให้ transaction ไม่ชนกัน
+	repo, err := NewSQLUserRepository(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Close ปิดการเชื่อมต่อฐานข้อมูล
+func (repo *SQLUserRepository) Close() error {
+	return repo.db.Close()
+}
+
+// migrate ปรับ schema ตามขั้นตอนใน migrations ที่ยังไม่เคยทำ โดยบันทึกเวอร์ชันที่ทำแล้วในตาราง schema_migrations
+// แต่ละขั้นตอนทำภายใน transaction ของตัวเอง หากล้มเหลวจะไม่มีผลกับฐานข้อมูล
+func migrate(db *sql.DB, migrations []string) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	if current > len(migrations) { // ฐานข้อมูลถูกสร้างโดยโปรแกรมเวอร์ชันที่ใหม่กว่า
+		return fmt.Errorf("database schema version %d is newer than supported version %d", current, len(migrations))
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate to version %d: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+			version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("Migrated user database to version %d", version) // บันทึกการปรับ schema ใน log
+	}
+	return nil
+}
+
+// rowScanner ใช้ได้ทั้ง *sql.Row และ *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanUser อ่านผู้ใช้หนึ่งแถว โดยคอลัมน์ id, username และ email เป็นข้อมูลหลักแทนค่าใน JSON
+func scanUser(row rowScanner) (*domain.User, error) {
+	var (
+		user                  domain.User
+		id                    int64
+		username, email, data string
+	)
+	if err := row.Scan(&id, &username, &email, &data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(data), &user); err != nil {
+		return nil, fmt.Errorf("decode user %d: %w", id, err)
+	}
+	user.ID, user.Username, user.Email = id, username, email
+	return &user, nil
+}
+
+// encodeUser แปลงผู้ใช้เป็น JSON สำหรับคอลัมน์ data
+func encodeUser(user *domain.User) (string, error) {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// queryUser ดึงผู้ใช้หนึ่งคนตามเงื่อนไข where จากฐานข้อมูลหรือ transaction
+func queryUser(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, where string, args ...any) (*domain.User, error) {
+	return scanUser(q.QueryRow(`SELECT id, username, email, data FROM users WHERE `+where, args...))
+}
+
+// exists ตรวจสอบว่ามีแถวที่ตรงกับเงื่อนไข where ภายใน transaction หรือไม่
+func exists(tx *sql.Tx, where string, args ...any) (bool, error) {
+	var found int
+	err := tx.QueryRow(`SELECT 1 FROM users WHERE `+where+` LIMIT 1`, args...).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetByID ดึงข้อมูลผู้ใช้ตามรหัสประจำตัว (ID)
+func (repo *SQLUserRepository) GetByID(id int64) (*domain.User, error) {
+	return queryUser(repo.db, `id = ?`, id)
+}
+
+// GetByUsername ดึงข้อมูลผู้ใช้ตามชื่อผู้ใช้
+func (repo *SQLUserRepository) GetByUsername(username string) (*domain.User, error) {
+	return queryUser(repo.db, `username = ?`, username)
+}
+
+// GetByEmail ดึงข้อมูลผู้ใช้ตามอีเมลที่ normalize แล้ว
+func (repo *SQLUserRepository) GetByEmail(email string) (*domain.User, error) {
+	if email == "" { // ผู้ใช้ที่ไม่มีอีเมลไม่ถูกค้นหาด้วยอีเมลว่าง
+		return nil, ErrUserNotFound
+	}
+	return queryUser(repo.db, `email = ?`, email)
+}
+
+// GetAll ดึงข้อมูลผู้ใช้ทั้งหมด
+func (repo *SQLUserRepository) GetAll() ([]*domain.User, error) {
+	rows, err := repo.db.Query(`SELECT id, username, email, data FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*domain.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Create เพิ่มผู้ใช้ใหม่ภายใน transaction และกำหนด ID ให้กับ user
+func (repo *SQLUserRepository) Create(user *domain.User) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // ไม่มีผลหาก Commit สำเร็จแล้ว
+
+	// ตรวจสอบชื่อผู้ใช้และอีเมลซ้ำ
+	if taken, err := exists(tx, `username = ?`, user.Username); err != nil {
+		return err
+	} else if taken {
+		return ErrUserExists
+	}
+	if user.Email != "" {
+		if taken, err := exists(tx, `email = ?`, user.Email); err != nil {
+			return err
+		} else if taken {
+			return ErrEmailExists
+		}
+	}
+
+	data, err := encodeUser(user)
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(`INSERT INTO users (username, email, data) VALUES (?, ?, ?)`, user.Username, user.Email, data)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	user.ID = id                                                       // กำหนดค่า ID ของผู้ใช้ใหม่ให้กับ user หลังบันทึกสำเร็จ
+	log.Printf("Created user: %s with ID: %d", user.Username, user.ID) // บันทึกการสร้างผู้ใช้ใหม่ใน log
+	return nil
+}
+
+// Update ปรับปรุงข้อมูลผู้ใช้ภายใน transaction การเปลี่ยนชื่อผู้ใช้ต้องทำผ่าน Rename
+func (repo *SQLUserRepository) Update(user *domain.User) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	previous, err := queryUser(tx, `id = ?`, user.ID)
+	if err != nil {
+		return err
+	}
+	if previous.Username != user.Username {
+		return ErrUsernameMismatch
+	}
+	if user.Email != "" { // อีเมลใหม่ต้องไม่ถูกใช้โดยผู้ใช้อื่น
+		if taken, err := exists(tx, `email = ? AND id <> ?`, user.Email, user.ID); err != nil {
+			return err
+		} else if taken {
+			return ErrEmailExists
+		}
+	}
+
+	data, err := encodeUser(user)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE users SET email = ?, data = ? WHERE id = ?`, user.Email, data, user.ID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Updated user: %s", user.Username) // บันทึกการปรับปรุงข้อมูลผู้ใช้ใน log
+	return nil
+}
+
+// Rename เปลี่ยนชื่อผู้ใช้ตามรหัสประจำตัว (ID) ภายใน transaction และบันทึกชื่อเดิมไว้ใน PreviousUsernames
+func (repo *SQLUserRepository) Rename(id int64, newUsername string) (*domain.User, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user, err := queryUser(tx, `id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if taken, err := exists(tx, `username = ?`, newUsername); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrUserExists
+	}
+
+	previous := user.Username
+	user.Username = newUsername
+	user.PreviousUsernames = append(user.PreviousUsernames, domain.UsernameChange{
+		Username:  previous,
+		ChangedAt: time.Now(),
+	})
+	data, err := encodeUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE users SET username = ?, data = ? WHERE id = ?`, newUsername, data, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Renamed user: %s to %s", previous, newUsername) // บันทึกการเปลี่ยนชื่อผู้ใช้ใน log
+	return user, nil
+}
+
+// Delete ลบผู้ใช้ออกจากฐานข้อมูลอย่างถาวร ตามรหัสประจำตัว (ID)
+func (repo *SQLUserRepository) Delete(id int64) error {
+	var username string
+	err := repo.db.QueryRow(`DELETE FROM users WHERE id = ? RETURNING username`, id).Scan(&username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Deleted user: %s with ID: %d", username, id) // บันทึกการลบผู้ใช้ใน log
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"Basic_login/domain"
 	"errors"
 	"log"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 )
@@ -13,6 +15,9 @@ var (
 	// สร้าง errors.New และกำหนดค่าข้อความให้ตัวแปร ErrUserNotFound และ ErrUserExists
 	ErrUserNotFound = errors.New("user not found")
 	ErrUserExists   = errors.New("user already exists")
+	ErrEmailExists  = errors.New("email already in use")
+	// ErrUsernameMismatch ใช้เมื่อ Update ได้รับชื่อผู้ใช้ที่ไม่ตรงกับ ID ซึ่งต้องเปลี่ยนผ่าน Rename แทน
+	ErrUsernameMismatch = errors.New("username does not match user ID, use Rename to change it")
 )
 
 // สร้าง struct Repository ที่มีฟิลด์ชื่อ users ของ type []domain.User และ mutex ของ type sync.Mutex
@@ -21,20 +26,18 @@ type InMemoryUserRepository struct {
 	userIDCounter int64                   // เพิ่มตัวเลขเพื่อสร้าง ID ของผู้ใช้
 	users         map[string]*domain.User // สร้าง map สําหรับเก็บข้อมูลผู้ใช้ โดยค่าจะเป็นตัวชี้ไปยังโครงสร้าง User
 	userIDs       map[int64]*domain.User  // สร้าง map สำหรับเก็บผู้ใช้ตามรหัสประจำตัว
-	chatRoom      *domain.ChatRoom        // ตัวแปรที่เก็บข้อมูลของห้องสนทนา
+	emails        map[string]*domain.User // สร้าง map สำหรับเก็บผู้ใช้ตามอีเมล (เฉพาะผู้ใช้ที่มีอีเมล)
 }
 
-// ฟังก์ชัน NewInMemoryUserRepository พร้อมกับการกำหนดขนาดของ buffer สำหรับห้องสนทนา
-func NewInMemoryUserRepository(bufferSize int) *InMemoryUserRepository {
+// ฟังก์ชัน NewInMemoryUserRepository สร้าง repository ผู้ใช้ในหน่วยความจำที่ยังไม่มีข้อมูล
+func NewInMemoryUserRepository() *InMemoryUserRepository {
 	repo := &InMemoryUserRepository{ // repo เป็น pointer ไปยัง InMemoryUserRepository ใหม่
 		// ซึ่งมีการสร้าง map สำหรับเก็บผู้ใช้ และรหัสประจำตัวผู้ใช้
 		users:   make(map[string]*domain.User),
 		userIDs: make(map[int64]*domain.User),
-		// สร้างห้องสนทนาพร้อมกับขนาด buffer ที่กำหนด
-		chatRoom: domain.NewChatRoom(bufferSize),
+		emails:  make(map[string]*domain.User),
 	}
-	go repo.chatRoom.Run() // จะเริ่มการทำงานของฟังก์ชัน Run ใน goroutine ใหม่ เพื่อให้ห้องสนทนาสามารถทำงานได้ในพื้นหลัง
-	return repo            // คืนค่า repo ซึ่งเป็น instance ของ InMemoryUserRepository
+	return repo // คืนค่า repo ซึ่งเป็น instance ของ InMemoryUserRepository
 }
 
 // ฟังก์ชัน GetByID ใช้ในการดึงข้อมูลผู้ใช้จาก InMemoryUserRepository ตามรหัสประจำตัว (ID)
@@ -47,7 +50,7 @@ func (repo *InMemoryUserRepository) GetByID(id int64) (*domain.User, error) {
 	if !exists {                     // ถ้าผู้ใช้ไม่พบ ฟังก์ชันจะคืนค่า nil และส่งคืนข้อผิดพลาด
 		return nil, ErrUserNotFound
 	}
-	return user, nil // หากผู้ใช้พบ ฟังก์ชันจะคืนค่า pointer ไปยัง user ที่ค้นพบ
+	return cloneUser(user), nil // หากผู้ใช้พบ ฟังก์ชันจะคืนค่าสำเนาของ user ที่ค้นพบ
 }
 
 // ฟังก์ชัน Create ใช้ในการเพิ่มผู้ใช้ใหม่ลงใน InMemoryUserRepository
@@ -60,17 +63,21 @@ func (repo *InMemoryUserRepository) Create(user *domain.User) error {
 	if _, exists := repo.users[user.Username]; exists { // ตรวจสอบว่าผู้ใช้ที่มีชื่อผู้ใช้นีี้มีอยู่แล้วใน repository หรือไม่
 		return ErrUserExists // ถ้ามีอยู่แล้ว ฟังก์ชันจะคืนค่าข้อผิดพลาด
 	}
+	if _, exists := repo.emails[user.Email]; user.Email != "" && exists { // ตรวจสอบว่าอีเมลถูกใช้โดยผู้ใช้อื่นแล้วหรือไม่
+		return ErrEmailExists
+	}
 
 	// เพิ่มผู้ใช้ใหม่
 	repo.userIDCounter++                                               // เพิ่มค่าตัวนับ ID ของผู้ใช้ใหม่
 	user.ID = repo.userIDCounter                                       // กำหนดค่า ID ของผู้ใช้ใหม่ให้กับ user
-	repo.users[user.Username] = user                                   // เพิ่มผู้ใช้ใหม่ลงใน users
-	repo.userIDs[user.ID] = user                                       // เพิ่มผู้ใช้ใหม่ลงใน userIDs
+	stored := cloneUser(user)                                          // เก็บสำเนา เพื่อไม่ให้ผู้เรียกแก้ไขข้อมูลใน repository โดยไม่ผ่าน Update
+	repo.users[stored.Username] = stored                               // เพิ่มผู้ใช้ใหม่ลงใน users
+	repo.userIDs[stored.ID] = stored                                   // เพิ่มผู้ใช้ใหม่ลงใน userIDs
 	log.Printf("Created user: %s with ID: %d", user.Username, user.ID) // บันทึกการสร้างผู้ใช้ใหม่ใน log
-
-	// เข้าร่วมในห้องสนทนา
-	repo.chatRoom.Join <- user.Username // ส่งชื่อผู้ใช้ไปยัง channel ของห้องสนทนาเพื่อให้ผู้ใช้เข้าร่วม
-	return nil                          // คืนค่า nil ถ้าการสร้างผู้ใช้สําเร็จ
+	if stored.Email != "" {
+		repo.emails[stored.Email] = stored // เพิ่มผู้ใช้ใหม่ลงใน emails
+	}
+	return nil // คืนค่า nil ถ้าการสร้างผู้ใช้สําเร็จ
 }
 
 // ฟังก์ชัน GetByUsername มีพารามิเตอร์ username ในการระบุชื่อผู้ใช้ และคืนค่า user และดึงข้อมูลจาก InMemoryUserRepository
@@ -84,7 +91,19 @@ func (repo *InMemoryUserRepository) GetByUsername(username string) (*domain.User
 	if !exists {                         // ถ้าผู้ใช้ไม่พบ ฟังก์ชันจะคืนค่า nil และส่งคืนข้อผิดพลาด
 		return nil, ErrUserNotFound
 	}
-	return user, nil // หากผู้ใช้พบ ฟังก์ชันจะคืนค่า user ไปยัง pointer domain.User
+	return cloneUser(user), nil // หากผู้ใช้พบ ฟังก์ชันจะคืนค่าสำเนาของ user
+}
+
+// ฟังก์ชัน GetByEmail ใช้ในการดึงข้อมูลผู้ใช้จาก InMemoryUserRepository ตามอีเมลที่ normalize แล้ว
+func (repo *InMemoryUserRepository) GetByEmail(email string) (*domain.User, error) {
+	repo.mu.RLock()         // ทำการล็อกการอ่าน เพื่อป้องกันไม่ให้มีการเปลี่ยนแปลงข้อมูลในขณะทำการอ่าน
+	defer repo.mu.RUnlock() // ใช้เพื่อปลดล็อคเมื่อฟังก์ชันสิ้นสุด
+
+	user, exists := repo.emails[email] // ตรวจสอบว่าอีเมลมีอยู่ใน map ของ emails หรือไม่
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	return cloneUser(user), nil
 }
 
 // ฟังก์ชัน GetAll ใช้ในการดึงข้อมูลผู้ใช้ทั้งหมดจาก InMemoryUserRepository และคืนค่า users และ error
@@ -97,7 +116,7 @@ func (repo *InMemoryUserRepository) GetAll() ([]*domain.User, error) {
 
 	// เพิ่มผู้ใช้ลงใน slice
 	for _, user := range repo.users { // ใช้ for เพื่อวนลอบผู้ใช้ใน map users
-		users = append(users, user) // เพิ่มผู้ใช้แต่ละคนลงใน slice
+		users = append(users, cloneUser(user)) // เพิ่มสำเนาของผู้ใช้แต่ละคนลงใน slice
 	}
 
 	// ฟังก์ชันนี้จะคืนค่า slice ของผู้ใช้ทั้งหมดพร้อมกับ error
@@ -110,14 +129,30 @@ func (repo *InMemoryUserRepository) Update(user *domain.User) error {
 	defer repo.mu.Unlock() // ใช้เพื่อปลดล็อคเมื่อฟังก์ชันสิ้นสุด
 
 	// ตรวจสอบผู้ใช้
-	if _, exists := repo.users[user.Username]; !exists { // ตรวจสอบว่าผู้ใช้ที่ต้องการอัปเดตมีอยู่ใน repository หรือไม่
+	previous, exists := repo.userIDs[user.ID] // ตรวจสอบว่าผู้ใช้ที่ต้องการอัปเดตมีอยู่ใน repository หรือไม่
+	if !exists {
 		return ErrUserNotFound // ถ้าผู้ใช้ไม่พบ ฟังก์ชันจะคืนค่า ErrUserNotFound
 	}
+	if previous.Username != user.Username { // การเปลี่ยนชื่อผู้ใช้ต้องทำผ่าน Rename เพื่อให้ users และ userIDs ตรงกัน
+		return ErrUsernameMismatch
+	}
+
+	// ตรวจสอบอีเมล
+	if owner, exists := repo.emails[user.Email]; user.Email != "" && exists && owner.ID != user.ID { // อีเมลใหม่ถูกใช้โดยผู้ใช้อื่นแล้ว
+		return ErrEmailExists
+	}
+	if previous.Email != "" {
+		delete(repo.emails, previous.Email) // ลบอีเมลเดิมออกจาก emails
+	}
 
 	// อัปเดตข้อมูลผู้ใช้
-	// จะอัปเดตข้อมูลผู้ใช้ใน repo.users และ repo.userIDs ด้วยค่าใหม่จากผู้ใช้ที่รับเข้ามา
-	repo.users[user.Username] = user
-	repo.userIDs[user.ID] = user
+	// จะอัปเดตข้อมูลผู้ใช้ใน repo.users, repo.userIDs และ repo.emails ด้วยสำเนาของผู้ใช้ที่รับเข้ามา
+	stored := cloneUser(user)
+	repo.users[stored.Username] = stored
+	repo.userIDs[stored.ID] = stored
+	if stored.Email != "" {
+		repo.emails[stored.Email] = stored
+	}
 
 	// บันทึกข้อมูล
 	log.Printf("Updated user: %s", user.Username) // บันทึกการปรับปรุงข้อมูลผู้ใช้ใน log
@@ -125,21 +160,128 @@ func (repo *InMemoryUserRepository) Update(user *domain.User) error {
 	return nil // คืนค่า nil ถ้าการปรับปรุงข้อมูลผู้ใช้สําเร็จ
 }
 
-// ฟังก์ชัน SendChatMessage ใช้ในการส่งข้อความไปยัง chat room กำหนดพารามิเตอร์ sender ชื่อผู้ส่ง และ message ข้อความที่ต้องการส่ง
-// โดยดึงข้อมูลผู้ใช้จาก InMemoryUserRepository
-func (repo *InMemoryUserRepository) SendChatMessage(sender, message string) {
-	// สร้างโครงสร้างข้อความของแชทใหม่ โดยตั้งค่าฟิลด์
-	chatMessage := domain.ChatMessage{
-		Sender:    sender,     // ชื่อผู้ส่ง
-		Message:   message,    // ข้อความที่ส่ง
-		TimeStamp: time.Now(), // เวลาที่ส่งข้อความ
+// ฟังก์ชัน Rename ใช้ในการเปลี่ยนชื่อผู้ใช้ตามรหัสประจำตัว (ID) โดยย้ายข้อมูลใน users และ userIDs พร้อมกันภายใต้ล็อกเดียว
+// และบันทึกชื่อเดิมไว้ใน PreviousUsernames
+func (repo *InMemoryUserRepository) Rename(id int64, newUsername string) (*domain.User, error) {
+	repo.mu.Lock()         // ทำการล็อกการเขียน
+	defer repo.mu.Unlock() // ใช้เพื่อปลดล็อคเมื่อฟังก์ชันสิ้นสุด
+
+	user, exists := repo.userIDs[id] // ตรวจสอบว่ามีผู้ใช้ตามรหัสประจำตัวที่ระบุหรือไม่
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	if _, taken := repo.users[newUsername]; taken { // ชื่อใหม่ถูกใช้โดยผู้ใช้อื่นแล้ว
+		return nil, ErrUserExists
+	}
+
+	// สร้างข้อมูลผู้ใช้ใหม่แทนการแก้ไขข้อมูลเดิม เพื่อไม่ให้ผู้ที่ถือ pointer เดิมเห็นข้อมูลที่เปลี่ยนไปครึ่งทาง
+	renamed := *user
+	renamed.Username = newUsername
+	renamed.PreviousUsernames = append(append([]domain.UsernameChange(nil), user.PreviousUsernames...), domain.UsernameChange{
+		Username:  user.Username,
+		ChangedAt: time.Now(),
+	})
+
+	// ย้ายข้อมูลในทุก map
+	delete(repo.users, user.Username)
+	repo.users[newUsername] = &renamed
+	repo.userIDs[id] = &renamed
+	if renamed.Email != "" {
+		repo.emails[renamed.Email] = &renamed
+	}
+
+	log.Printf("Renamed user: %s to %s", user.Username, newUsername) // บันทึกการเปลี่ยนชื่อผู้ใช้ใน log
+	return cloneUser(&renamed), nil
+}
+
+// ฟังก์ชัน Delete ใช้ในการลบผู้ใช้ออกจาก InMemoryUserRepository อย่างถาวร ตามรหัสประจำตัว (ID)
+func (repo *InMemoryUserRepository) Delete(id int64) error {
+	repo.mu.Lock()         // ทำการล็อกการเขียน
+	defer repo.mu.Unlock() // ใช้เพื่อปลดล็อคเมื่อฟังก์ชันสิ้นสุด
+
+	user, exists := repo.userIDs[id] // ตรวจสอบว่ามีผู้ใช้ตามรหัสประจำตัวที่ระบุหรือไม่
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	// ลบผู้ใช้ออกจากทุก map
+	repo.removeLocked(user)
+
+	log.Printf("Deleted user: %s with ID: %d", user.Username, id) // บันทึกการลบผู้ใช้ใน log
+	return nil
+}
+
+// put เพิ่มหรือแทนที่ผู้ใช้ตาม ID โดยตรงโดยไม่ตรวจสอบความซ้ำ ใช้สำหรับกู้คืนข้อมูลจากไฟล์และย้อนกลับการเปลี่ยนแปลง
+func (repo *InMemoryUserRepository) put(user *domain.User) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if previous, exists := repo.userIDs[user.ID]; exists { // ลบดัชนีเดิมของผู้ใช้ก่อน เผื่อชื่อหรืออีเมลเปลี่ยน
+		repo.removeLocked(previous)
+	}
+	repo.users[user.Username] = user
+	repo.userIDs[user.ID] = user
+	if user.Email != "" {
+		repo.emails[user.Email] = user
+	}
+	if user.ID > repo.userIDCounter { // ไม่ให้ ID ใหม่ซ้ำกับผู้ใช้ที่กู้คืนมา
+		repo.userIDCounter = user.ID
+	}
+}
+
+// remove ลบผู้ใช้ตาม ID โดยไม่บันทึก log และคืนค่าผู้ใช้ที่ถูกลบ (nil หากไม่พบ)
+func (repo *InMemoryUserRepository) remove(id int64) *domain.User {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.userIDs[id]
+	if !exists {
+		return nil
+	}
+	repo.removeLocked(user)
+	return user
+}
+
+// removeLocked ลบผู้ใช้ออกจากทุก map โดยผู้เรียกต้องถือล็อกการเขียนอยู่แล้ว
+func (repo *InMemoryUserRepository) removeLocked(user *domain.User) {
+	delete(repo.users, user.Username)
+	delete(repo.userIDs, user.ID)
+	if user.Email != "" {
+		delete(repo.emails, user.Email)
+	}
+}
+
+// snapshot คืนค่าผู้ใช้ทั้งหมดเรียงตาม ID พร้อมค่าตัวนับ ID ปัจจุบัน
+func (repo *InMemoryUserRepository) snapshot() ([]*domain.User, int64) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	users := make([]*domain.User, 0, len(repo.userIDs))
+	for _, user := range repo.userIDs {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, repo.userIDCounter
+}
+
+// advanceCounter เลื่อนตัวนับ ID ไปอย่างน้อย counter เพื่อไม่ให้ ID ของผู้ใช้ที่ถูกลบไปแล้วถูกใช้ซ้ำ
+func (repo *InMemoryUserRepository) advanceCounter(counter int64) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if counter > repo.userIDCounter {
+		repo.userIDCounter = counter
 	}
-	// ส่งข้อความไปยัง channels Messages ของห้องสนทนา ซึ่งจะทำให้ห้องสนทนาได้รับข้อความ
-	repo.chatRoom.Messages <- chatMessage
 }
 
-// ฟังก์ชัน LeaveChat กำหนดพารามิเตอร์ username ใช้ในการนำผู้ใช้ออกจากห้องสนทนา (chat room)
-func (repo *InMemoryUserRepository) LeaveChat(username string) {
-	// พารามิเตอร์ username ชื่อผู้ใช้ที่ต้องการออกจากห้องสนทนา
-	repo.chatRoom.Leave <- username // ส่งชื่อผู้ใช้ไปยัง channel Leave ของห้องสนทนา
+// cloneUser คัดลอกผู้ใช้รวมถึง slice ทั้งหมด เพื่อให้ข้อมูลที่คืนค่าหรือเก็บไว้ไม่ใช้หน่วยความจำร่วมกับผู้เรียก
+// ซึ่งตรงกับพฤติกรรมของ SQLUserRepository ที่สร้างผู้ใช้ใหม่ทุกครั้งที่อ่าน
+func cloneUser(user *domain.User) *domain.User {
+	clone := *user
+	clone.Salt = slices.Clone(user.Salt)
+	clone.PreviousUsernames = slices.Clone(user.PreviousUsernames)
+	clone.TOTPSecret = slices.Clone(user.TOTPSecret)
+	clone.TOTPPendingSecret = slices.Clone(user.TOTPPendingSecret)
+	clone.RecoveryCodes = slices.Clone(user.RecoveryCodes)
+	return &clone
 }
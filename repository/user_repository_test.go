@@ -0,0 +1,79 @@
+package repository_test
+
+import (
+	"Basic_login/domain"
+	"Basic_login/repository"
+	"Basic_login/repository/repotest"
+	"Basic_login/usecase"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestInMemoryUserRepository(t *testing.T) {
+	repotest.TestUserRepository(t, func(t *testing.T) usecase.UserRepository {
+		return repository.NewInMemoryUserRepository()
+	})
+}
+
+func TestFileUserRepository(t *testing.T) {
+	repotest.TestUserRepository(t, func(t *testing.T) usecase.UserRepository {
+		repo, err := repository.NewFileUserRepository(filepath.Join(t.TempDir(), "users.db"))
+		if err != nil {
+			t.Fatalf("NewFileUserRepository error = %v", err)
+		}
+		repo.CompactEvery = 4 // ให้การทดสอบผ่านการรวม journal เป็น snapshot ด้วย
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	})
+}
+
+// TestFileUserRepositoryReopenAfterCompaction ตรวจสอบว่าระเบียนที่เขียนหลังการรวม journal อัตโนมัติ
+// ยังอ่านกลับได้เมื่อเปิดไฟล์ใหม่โดยไม่ได้เรียก Close (เช่น โปรแกรมหยุดทำงานกะทันหัน)
+func TestFileUserRepositoryReopenAfterCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.db")
+	repo, err := repository.NewFileUserRepository(path)
+	if err != nil {
+		t.Fatalf("NewFileUserRepository error = %v", err)
+	}
+	repo.CompactEvery = 4
+
+	const total = 10 // มากกว่า CompactEvery เพื่อให้มีระเบียนหลังการรวมอย่างน้อยหนึ่งครั้ง
+	for i := 0; i < total; i++ {
+		user := &domain.User{Username: fmt.Sprintf("user%d", i), Role: "user", Password: "hash"}
+		if err := repo.Create(user); err != nil {
+			t.Fatalf("Create(%d) error = %v", i, err)
+		}
+	}
+	repo.Abandon() // ไม่เรียก Close จึงไม่มีการรวม journal ครั้งสุดท้าย
+
+	reopened, err := repository.NewFileUserRepository(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	t.Cleanup(func() { reopened.Close() })
+
+	users, err := reopened.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll error = %v", err)
+	}
+	if len(users) != total {
+		t.Fatalf("GetAll returned %d users, want %d", len(users), total)
+	}
+	for i := 0; i < total; i++ {
+		if _, err := reopened.GetByUsername(fmt.Sprintf("user%d", i)); err != nil {
+			t.Errorf("GetByUsername(user%d) error = %v", i, err)
+		}
+	}
+}
+
+func TestSQLUserRepository(t *testing.T) {
+	repotest.TestUserRepository(t, func(t *testing.T) usecase.UserRepository {
+		repo, err := repository.OpenSQLiteUserRepository(filepath.Join(t.TempDir(), "users.sqlite"))
+		if err != nil {
+			t.Fatalf("OpenSQLiteUserRepository error = %v", err)
+		}
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	})
+}
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"time"
+)
+
+// DisableUser ระงับบัญชีผู้ใช้ และยกเลิกเซสชันทั้งหมดของผู้ใช้
+func (u *UserUsecase) DisableUser(actor *domain.User, targetID int64) error {
+	return u.changeAccountStatus(actor, targetID, domain.StatusDisabled, domain.PermUserDisable)
+}
+
+// EnableUser เปิดใช้งานบัญชีที่ถูกระงับหรือรอการเปิดใช้งาน รวมถึงกู้คืนบัญชีที่ถูกลบแบบ soft delete ที่ยังไม่พ้นระยะเวลาเก็บรักษา
+func (u *UserUsecase) EnableUser(actor *domain.User, targetID int64) error {
+	return u.changeAccountStatus(actor, targetID, domain.StatusActive, domain.PermUserDisable)
+}
+
+// SoftDeleteUser ลบบัญชีแบบ soft delete บัญชีจะถูกลบถาวรโดย PurgeDeletedUsers เมื่อพ้น Config.DeletedUserRetention
+func (u *UserUsecase) SoftDeleteUser(actor *domain.User, targetID int64) error {
+	return u.changeAccountStatus(actor, targetID, domain.StatusDeleted, domain.PermUserDelete)
+}
+
+// HardDeleteUser ลบบัญชีผู้ใช้ออกจาก UserRepo อย่างถาวรทันที
+func (u *UserUsecase) HardDeleteUser(actor *domain.User, targetID int64) error {
+	target, err := u.UserRepo.GetByID(targetID) // ดึงข้อมูลผู้ใช้ที่ต้องการลบ
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserDelete, domain.UserResource(target)); err != nil {
+		return err
+	}
+	if err := u.deleteUser(target); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("User deleted permanently by %s: %s\n", actor.Username, target.Username) // บันทึกการลบถาวรในล็อก
+	return nil
+}
+
+// PurgeDeletedUsers ลบบัญชีที่ถูกลบแบบ soft delete นานเกินระยะเวลาเก็บรักษาอย่างถาวร และคืนค่าจำนวนบัญชีที่ถูกลบ
+func (u *UserUsecase) PurgeDeletedUsers(actor *domain.User) (int, error) {
+	if err := u.Authorize(actor, domain.PermUserDelete, domain.Resource{Kind: domain.ResourceUser}); err != nil {
+		return 0, err
+	}
+
+	users, err := u.UserRepo.GetAll() // ดึงผู้ใช้ทั้งหมด
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-u.Config.DeletedUserRetention) // บัญชีที่ถูกลบก่อนเวลานี้จะถูกลบถาวร
+	purged := 0
+	for _, user := range users {
+		if user.EffectiveStatus() != domain.StatusDeleted || user.DeletedAt.After(cutoff) {
+			continue
+		}
+		if err := u.deleteUser(user); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	if purged > 0 {
+		u.Logger.Printf("Purged %d deleted users by %s\n", purged, actor.Username) // บันทึกจำนวนบัญชีที่ถูกลบถาวรในล็อก
+	}
+	return purged, nil
+}
+
+// changeAccountStatus เปลี่ยนสถานะของบัญชี โดยผู้ดำเนินการต้องมีสิทธิ์ permission กับบัญชีเป้าหมาย
+func (u *UserUsecase) changeAccountStatus(actor *domain.User, targetID int64, status domain.AccountStatus, permission domain.Permission) error {
+	target, err := u.UserRepo.GetByID(targetID) // ดึงข้อมูลผู้ใช้ที่ต้องการเปลี่ยนสถานะ
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, permission, domain.UserResource(target)); err != nil {
+		return err
+	}
+
+	if _, err := u.modifyUser(target.ID, func(updated *domain.User) error {
+		return u.applyStatus(updated, status)
+	}); err != nil {
+		return err
+	}
+
+	if status != domain.StatusActive { // บัญชีที่ใช้งานไม่ได้ต้องไม่มีเซสชันค้างอยู่
+		u.revokeUserSessions(target)
+	}
+
+	u.Logger.Printf("Account status changed by %s: %s is now %s\n", actor.Username, target.Username, status) // บันทึกการเปลี่ยนสถานะในล็อก
+	return nil
+}
+
+// deleteUser ลบผู้ใช้ เซสชัน และการเป็นสมาชิกกลุ่มของผู้ใช้ออกอย่างถาวร
+func (u *UserUsecase) deleteUser(user *domain.User) error {
+	if err := u.UserRepo.Delete(user.ID); err != nil {
+		return err
+	}
+	if u.GroupRepo != nil { // นำผู้ใช้ออกจากทุกกลุ่ม เพื่อไม่ให้ผู้ใช้ใหม่ที่ได้ ID เดียวกันได้รับสิทธิ์ของกลุ่ม
+		groups, err := u.GroupRepo.GetByMember(user.ID)
+		if err != nil {
+			u.Logger.Printf("Failed to load groups for %s: %v\n", user.Username, err)
+		}
+		for _, group := range groups {
+			if err := u.removeMember(group, user.ID); err != nil {
+				u.Logger.Printf("Failed to remove %s from group %s: %v\n", user.Username, group.Name, err)
+			}
+		}
+	}
+	u.revokeUserSessions(user)
+	return nil
+}
+
+// applyStatus ตั้งสถานะ status ให้ user พร้อมเวลาที่ลบ บัญชีที่ถูกลบนานเกินระยะเวลาเก็บรักษาจะกู้คืนไม่ได้
+func (u *UserUsecase) applyStatus(user *domain.User, status domain.AccountStatus) error {
+	if user.EffectiveStatus() == domain.StatusDeleted && status == domain.StatusActive &&
+		time.Since(user.DeletedAt) > u.Config.DeletedUserRetention { // พ้นระยะเวลาเก็บรักษาแล้ว กู้คืนไม่ได้
+		return u.Constants.ErrAccountDeleted
+	}
+	user.Status = status
+	user.DeletedAt = time.Time{}
+	if status == domain.StatusDeleted {
+		user.DeletedAt = time.Now() // บันทึกเวลาที่ลบ เพื่อใช้คำนวณระยะเวลาเก็บรักษา
+	}
+	return nil
+}
+
+// revokeUserSessions ยกเลิกเซสชันและ refresh token ทั้งหมดของผู้ใช้ ข้อผิดพลาดจะถูกบันทึกไว้ในล็อกเท่านั้น
+func (u *UserUsecase) revokeUserSessions(user *domain.User) {
+	if u.SessionRepo != nil {
+		if err := u.SessionRepo.DeleteByUserID(user.ID); err != nil {
+			u.Logger.Printf("Failed to revoke sessions for %s: %v\n", user.Username, err)
+		}
+	}
+	if u.RefreshRepo != nil { // refresh token ที่ค้างอยู่ใช้ขอ access token ใหม่ได้ จึงต้องยกเลิกด้วย
+		if err := u.RefreshRepo.RevokeByUserID(user.ID); err != nil {
+			u.Logger.Printf("Failed to revoke refresh tokens for %s: %v\n", user.Username, err)
+		}
+	}
+}
+
+// checkAccountStatus คืนค่าข้อผิดพลาดที่ระบุสถานะ หากบัญชีไม่ได้อยู่ในสถานะใช้งาน
+func (u *UserUsecase) checkAccountStatus(user *domain.User) error {
+	return accountStatusError(user, u.Constants)
+}
+
+// accountStatusError แปลงสถานะของบัญชีเป็นข้อผิดพลาดใน constants ใช้ร่วมกันระหว่าง UserUsecase และ TokenService
+func accountStatusError(user *domain.User, constants *Constants) error {
+	switch user.EffectiveStatus() {
+	case domain.StatusActive:
+		return nil
+	case domain.StatusDisabled:
+		return constants.ErrAccountDisabled
+	case domain.StatusPending:
+		return constants.ErrAccountPending
+	default:
+		return constants.ErrAccountDeleted
+	}
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+)
+
+// RoleRepository interface สำหรับจัดเก็บบทบาทและชุดสิทธิ์
+type RoleRepository interface {
+	Create(role *domain.Role) error              // สร้างบทบาทใหม่
+	GetByName(name string) (*domain.Role, error) // ดึงบทบาทตามชื่อบทบาท
+	GetAll() ([]*domain.Role, error)             // ดึงบทบาททั้งหมด
+	Update(role *domain.Role) error              // ปรับปรุงชุดสิทธิ์และการสืบทอดของบทบาท
+	Delete(name string) error                    // ลบบทบาทตามชื่อบทบาท
+}
+
+// NewDefaultRoles สร้างบทบาทพื้นฐาน ผู้ดูแลระบบมีทุกสิทธิ์ ส่วนผู้ใช้ทั่วไปส่งข้อความได้และจัดการได้เฉพาะบัญชีของตนเอง
+func NewDefaultRoles(constants *Constants) []*domain.Role {
+	return []*domain.Role{
+		{
+			Name:        constants.RoleAdmin,
+			Permissions: domain.AllPermissions,
+		},
+		{
+			Name:           constants.RoleUser,
+			Permissions:    []domain.Permission{domain.PermChatSend},
+			OwnPermissions: []domain.Permission{domain.PermUserUpdate, domain.PermUserDelete, domain.PermUserCredentials},
+		},
+	}
+}
+
+// Authorize ตรวจสอบว่า actor มีสิทธิ์ permission กับ resource หรือไม่ และคืนค่า ErrPermissionDenied หากไม่มีสิทธิ์
+// สิทธิ์ของ actor คือผลรวมของสิทธิ์จากบทบาทของตนเอง และบทบาทและสิทธิ์ของทุกกลุ่มที่เป็นสมาชิก
+// บัญชีที่ไม่ได้อยู่ในสถานะใช้งานไม่มีสิทธิ์ใดๆ การดำเนินการที่ยืนยันด้วย secret เช่น ChangePassword (รหัสผ่านเดิม)
+// หรือ CompleteReset (โทเคน) ไม่ต้องผ่าน Authorize
+func (u *UserUsecase) Authorize(actor *domain.User, permission domain.Permission, resource domain.Resource) error {
+	if actor == nil || actor.EffectiveStatus() != domain.StatusActive {
+		return u.Constants.ErrPermissionDenied
+	}
+
+	permissions, ownPermissions := u.collectPermissions(actor)
+	own := resource.OwnerID != 0 && resource.OwnerID == actor.ID
+	if !permissions[permission] && !(own && ownPermissions[permission]) {
+		u.Logger.Printf("Permission denied: %s lacks %s on %s %d\n", actor.Username, permission, resource.Kind, resource.ID) // บันทึกการปฏิเสธสิทธิ์ในล็อก
+		return u.Constants.ErrPermissionDenied
+	}
+	return nil
+}
+
+// authorizeGrant ตรวจสอบว่า actor มอบบทบาท roles และสิทธิ์ permissions ให้ผู้อื่นได้หรือไม่
+// ผู้ดำเนินการต้องมีสิทธิ์ PermRoleAssign และต้องมีสิทธิ์ทุกข้อที่จะมอบอยู่แล้ว ทั้งสิทธิ์ใน permissions
+// และสิทธิ์ทั้งหมดของบทบาทใน roles รวมถึงที่สืบทอดมา เพื่อไม่ให้เพิ่มสิทธิ์ให้ตนเองหรือผู้อื่นเกินกว่าที่ตนมี
+func (u *UserUsecase) authorizeGrant(actor *domain.User, resource domain.Resource, roles []string, permissions []domain.Permission) error {
+	if len(roles) == 0 && len(permissions) == 0 { // ไม่มีสิทธิ์ที่ต้องมอบ
+		return nil
+	}
+	if err := u.Authorize(actor, domain.PermRoleAssign, resource); err != nil {
+		return err
+	}
+
+	granted := make(map[domain.Permission]bool)    // สิทธิ์ที่ใช้ได้กับทุก resource ที่จะมอบ
+	grantedOwn := make(map[domain.Permission]bool) // สิทธิ์ที่ใช้ได้เฉพาะ resource ของตนเองที่จะมอบ
+	visited := make(map[string]bool)
+	for _, role := range roles {
+		u.collectRolePermissions(role, granted, grantedOwn, visited)
+	}
+	for _, permission := range permissions {
+		granted[permission] = true
+	}
+
+	held, heldOwn := u.collectPermissions(actor)
+	for permission := range granted {
+		if !held[permission] {
+			u.Logger.Printf("Permission denied: %s cannot grant %s\n", actor.Username, permission) // บันทึกการปฏิเสธในล็อก
+			return u.Constants.ErrPermissionDenied
+		}
+	}
+	for permission := range grantedOwn {
+		if !held[permission] && !heldOwn[permission] {
+			u.Logger.Printf("Permission denied: %s cannot grant %s\n", actor.Username, permission) // บันทึกการปฏิเสธในล็อก
+			return u.Constants.ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// collectPermissions รวบรวมสิทธิ์ทั้งหมดของผู้ใช้จากบทบาทของผู้ใช้และกลุ่มที่เป็นสมาชิก
+func (u *UserUsecase) collectPermissions(user *domain.User) (map[domain.Permission]bool, map[domain.Permission]bool) {
+	permissions := make(map[domain.Permission]bool)    // สิทธิ์ที่ใช้ได้กับทุก resource
+	ownPermissions := make(map[domain.Permission]bool) // สิทธิ์ที่ใช้ได้เฉพาะ resource ของตนเอง
+	visited := make(map[string]bool)                   // บทบาทที่รวบรวมแล้ว ป้องกันการวนซ้ำ
+
+	u.collectRolePermissions(user.Role, permissions, ownPermissions, visited)
+	if u.GroupRepo == nil {
+		return permissions, ownPermissions
+	}
+
+	groups, err := u.GroupRepo.GetByMember(user.ID) // ดึงกลุ่มทั้งหมดที่ผู้ใช้เป็นสมาชิก
+	if err != nil {
+		u.Logger.Printf("Failed to load groups for %s: %v\n", user.Username, err)
+		return permissions, ownPermissions
+	}
+	for _, group := range groups {
+		for _, role := range group.Roles {
+			u.collectRolePermissions(role, permissions, ownPermissions, visited)
+		}
+		for _, permission := range group.Permissions {
+			permissions[permission] = true
+		}
+	}
+	return permissions, ownPermissions
+}
+
+// collectRolePermissions รวบรวมสิทธิ์ของบทบาท name รวมถึงสิทธิ์ที่สืบทอดจากบทบาทอื่น
+func (u *UserUsecase) collectRolePermissions(name string, permissions, ownPermissions map[domain.Permission]bool, visited map[string]bool) {
+	if visited[name] {
+		return
+	}
+	visited[name] = true
+
+	role, err := u.RoleRepo.GetByName(name) // ดึงชุดสิทธิ์ของบทบาท
+	if err != nil {
+		return
+	}
+	for _, permission := range role.Permissions {
+		permissions[permission] = true
+	}
+	for _, permission := range role.OwnPermissions {
+		ownPermissions[permission] = true
+	}
+	for _, parent := range role.Inherits {
+		u.collectRolePermissions(parent, permissions, ownPermissions, visited)
+	}
+}
+
+// seedDefaultRoles เพิ่มบทบาทพื้นฐานลงใน RoleRepo หากยังไม่มี
+func (u *UserUsecase) seedDefaultRoles() {
+	for _, role := range NewDefaultRoles(u.Constants) {
+		if _, err := u.RoleRepo.GetByName(role.Name); err == nil { // มีบทบาทนี้อยู่แล้ว
+			continue
+		}
+		if err := u.RoleRepo.Create(role); err != nil {
+			u.Logger.Printf("Failed to create default role %s: %v\n", role.Name, err)
+		}
+	}
+}
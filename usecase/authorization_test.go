@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"errors"
+	"testing"
+)
+
+func TestAuthorizeRoles(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	bob := mustCreateUser(t, u, "bobby", u.Constants.RoleUser)
+
+	tests := []struct {
+		name       string
+		actor      *domain.User
+		permission domain.Permission
+		resource   domain.Resource
+		allowed    bool
+	}{
+		{"user updates own account", alice, domain.PermUserUpdate, domain.UserResource(alice), true},
+		{"user updates other account", alice, domain.PermUserUpdate, domain.UserResource(bob), false},
+		{"user sends chat", alice, domain.PermChatSend, domain.Resource{Kind: domain.ResourceUser}, true},
+		{"user disables own account", alice, domain.PermUserDisable, domain.UserResource(alice), false},
+		{"admin updates other account", admin, domain.PermUserUpdate, domain.UserResource(bob), true},
+		{"admin manages roles", admin, domain.PermRoleManage, domain.Resource{}, true},
+		{"nil actor", nil, domain.PermChatSend, domain.Resource{}, false},
+	}
+	for _, tt := range tests {
+		err := u.Authorize(tt.actor, tt.permission, tt.resource)
+		if tt.allowed && err != nil {
+			t.Errorf("%s: Authorize error = %v, want nil", tt.name, err)
+		}
+		if !tt.allowed && !errors.Is(err, u.Constants.ErrPermissionDenied) {
+			t.Errorf("%s: Authorize error = %v, want ErrPermissionDenied", tt.name, err)
+		}
+	}
+}
+
+func TestAuthorizeDeniesInactiveActor(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	other := mustCreateUser(t, u, "other", u.Constants.RoleAdmin)
+	if err := u.DisableUser(other, admin.ID); err != nil {
+		t.Fatalf("DisableUser error = %v", err)
+	}
+
+	if err := u.Authorize(mustGetUser(t, u, admin.ID), domain.PermRoleManage, domain.Resource{}); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("Authorize by disabled admin error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestAuthorizeGroupGrants(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	bob := mustCreateUser(t, u, "bobby", u.Constants.RoleUser)
+	if err := u.RoleRepo.Create(&domain.Role{Name: "moderator", Permissions: []domain.Permission{domain.PermChatModerate}}); err != nil {
+		t.Fatalf("Create role error = %v", err)
+	}
+
+	group, err := u.CreateGroup(admin, "support")
+	if err != nil {
+		t.Fatalf("CreateGroup error = %v", err)
+	}
+	if err := u.AddGroupMember(admin, group.ID, alice.ID); err != nil {
+		t.Fatalf("AddGroupMember error = %v", err)
+	}
+	if err := u.SetGroupGrants(admin, group.ID, []string{"moderator"}, []domain.Permission{domain.PermUserUnlock}); err != nil {
+		t.Fatalf("SetGroupGrants error = %v", err)
+	}
+
+	for _, permission := range []domain.Permission{domain.PermUserUnlock, domain.PermChatModerate} { // สิทธิ์จากกลุ่มโดยตรงและจากบทบาทของกลุ่ม
+		if err := u.Authorize(alice, permission, domain.UserResource(bob)); err != nil {
+			t.Errorf("Authorize(member, %s) error = %v, want nil", permission, err)
+		}
+		if err := u.Authorize(bob, permission, domain.UserResource(alice)); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+			t.Errorf("Authorize(non-member, %s) error = %v, want ErrPermissionDenied", permission, err)
+		}
+	}
+
+	if err := u.RemoveGroupMember(admin, group.ID, alice.ID); err != nil {
+		t.Fatalf("RemoveGroupMember error = %v", err)
+	}
+	if err := u.Authorize(alice, domain.PermUserUnlock, domain.UserResource(bob)); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("Authorize after removal error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestAuthorizeInheritedRole(t *testing.T) {
+	u := newTestUsecase(t)
+	for _, role := range []*domain.Role{
+		{Name: "editor", Permissions: []domain.Permission{domain.PermRoomCreate}, Inherits: []string{"viewer"}},
+		{Name: "viewer", Permissions: []domain.Permission{domain.PermChatSend}, Inherits: []string{"editor"}}, // การสืบทอดวนกลับต้องไม่ทำให้วนซ้ำไม่รู้จบ
+	} {
+		if err := u.RoleRepo.Create(role); err != nil {
+			t.Fatalf("Create role %s error = %v", role.Name, err)
+		}
+	}
+	alice := mustCreateUser(t, u, "alice", "editor")
+
+	for _, permission := range []domain.Permission{domain.PermRoomCreate, domain.PermChatSend} {
+		if err := u.Authorize(alice, permission, domain.Resource{}); err != nil {
+			t.Errorf("Authorize(%s) error = %v, want nil", permission, err)
+		}
+	}
+	if err := u.Authorize(alice, domain.PermChatModerate, domain.Resource{}); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("Authorize(%s) error = %v, want ErrPermissionDenied", domain.PermChatModerate, err)
+	}
+}
+
+func TestGroupGrantsCannotEscalate(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	if err := u.RoleRepo.Create(&domain.Role{Name: "group-manager", Permissions: []domain.Permission{domain.PermGroupManage}}); err != nil {
+		t.Fatalf("Create role error = %v", err)
+	}
+	if err := u.RoleRepo.Create(&domain.Role{Name: "assigner", Permissions: []domain.Permission{domain.PermGroupManage, domain.PermRoleAssign}}); err != nil {
+		t.Fatalf("Create role error = %v", err)
+	}
+	manager := mustCreateUser(t, u, "manager", "group-manager")
+	assigner := mustCreateUser(t, u, "assigner", "assigner")
+
+	group, err := u.CreateGroup(manager, "escalate")
+	if err != nil {
+		t.Fatalf("CreateGroup error = %v", err)
+	}
+	if err := u.AddGroupMember(manager, group.ID, manager.ID); err != nil {
+		t.Fatalf("AddGroupMember error = %v", err)
+	}
+	escalation := []domain.Permission{domain.PermRoleManage, domain.PermRoleAssign, domain.PermUserCredentials}
+	if err := u.SetGroupGrants(manager, group.ID, nil, escalation); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("SetGroupGrants without role.assign error = %v, want ErrPermissionDenied", err)
+	}
+	if err := u.SetGroupGrants(assigner, group.ID, nil, escalation); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("SetGroupGrants of permissions not held error = %v, want ErrPermissionDenied", err)
+	}
+	if err := u.SetGroupGrants(assigner, group.ID, []string{u.Constants.RoleAdmin}, nil); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("SetGroupGrants of admin role error = %v, want ErrPermissionDenied", err)
+	}
+	if err := u.Authorize(manager, domain.PermRoleManage, domain.Resource{}); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("Authorize after escalation attempt error = %v, want ErrPermissionDenied", err)
+	}
+
+	admins, err := u.CreateGroup(admin, "admins")
+	if err != nil {
+		t.Fatalf("CreateGroup error = %v", err)
+	}
+	if err := u.SetGroupGrants(admin, admins.ID, []string{u.Constants.RoleAdmin}, nil); err != nil {
+		t.Fatalf("SetGroupGrants by admin error = %v", err)
+	}
+	if err := u.AddGroupMember(manager, admins.ID, manager.ID); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("AddGroupMember to admin group error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestAssignRoleCannotEscalate(t *testing.T) {
+	u := newTestUsecase(t)
+	if err := u.RoleRepo.Create(&domain.Role{
+		Name:        "assigner",
+		Permissions: []domain.Permission{domain.PermRoleAssign, domain.PermUserUpdate, domain.PermUserCreate},
+		Inherits:    []string{u.Constants.RoleUser},
+	}); err != nil {
+		t.Fatalf("Create role error = %v", err)
+	}
+	assigner := mustCreateUser(t, u, "assigner", "assigner")
+	bobby := mustCreateUser(t, u, "bobby", "assigner")
+
+	if err := u.AssignRole(assigner, assigner.ID, u.Constants.RoleAdmin); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("AssignRole(admin) error = %v, want ErrPermissionDenied", err)
+	}
+	edited := *mustGetUser(t, u, assigner.ID)
+	edited.Role = u.Constants.RoleAdmin
+	if err := u.Update(assigner, &edited); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("Update to admin error = %v, want ErrPermissionDenied", err)
+	}
+	if err := u.CreateUserAs(assigner, &domain.User{Username: "mallory", Password: testPassword}, u.Constants.RoleAdmin); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("CreateUserAs(admin) error = %v, want ErrPermissionDenied", err)
+	}
+	if got := mustGetUser(t, u, assigner.ID).Role; got != "assigner" {
+		t.Errorf("Role after escalation attempts = %q, want assigner", got)
+	}
+
+	// บทบาทที่ผู้ดำเนินการมีสิทธิ์ครบอยู่แล้วกำหนดได้ตามปกติ
+	if err := u.AssignRole(assigner, bobby.ID, u.Constants.RoleUser); err != nil {
+		t.Fatalf("AssignRole(user) error = %v", err)
+	}
+	if got := mustGetUser(t, u, bobby.ID).Role; got != u.Constants.RoleUser {
+		t.Errorf("Role = %q, want %q", got, u.Constants.RoleUser)
+	}
+}
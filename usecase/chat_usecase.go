@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"time"
+)
+
+// ChatRepository interface สำหรับห้องสนทนา แยกจาก UserRepository เพื่อให้ที่เก็บข้อมูลผู้ใช้ไม่ต้องรองรับการแชท
+type ChatRepository interface {
+	Join(username string)            // ให้ผู้ใช้ (username) เข้าร่วมห้องสนทนา
+	Leave(username string)           // ให้ผู้ใช้ (username) ออกจากห้องสนทนา
+	Send(message domain.ChatMessage) // ส่งข้อความไปยังห้องสนทนา
+}
+
+// Authorizer ตรวจสอบสิทธิ์ของผู้ใช้ ซึ่ง UserUsecase เป็นผู้ implement
+type Authorizer interface {
+	Authorize(actor *domain.User, permission domain.Permission, resource domain.Resource) error
+}
+
+// ChatUsecase ใช้สำหรับการดำเนินการที่เกี่ยวข้องกับห้องสนทนา
+type ChatUsecase struct {
+	ChatRepo   ChatRepository // ฟิลด์สำหรับการเข้าถึงห้องสนทนา
+	Authorizer Authorizer     // ฟิลด์สำหรับตรวจสอบสิทธิ์การส่งข้อความ
+}
+
+// NewChatUsecase สร้างและคืนค่า ChatUsecase ใหม่
+func NewChatUsecase(chatRepo ChatRepository, authorizer Authorizer) *ChatUsecase {
+	return &ChatUsecase{
+		ChatRepo:   chatRepo,   // กำหนดค่า ChatRepo จากพารามิเตอร์ chatRepo
+		Authorizer: authorizer, // กำหนดค่า Authorizer จากพารามิเตอร์ authorizer
+	}
+}
+
+// Subscribe สมัครรับเหตุการณ์ของผู้ใช้ เพื่อให้ผู้ใช้ใหม่เข้าร่วมห้องสนทนาโดยอัตโนมัติ
+func (c *ChatUsecase) Subscribe(events *UserEventBus) {
+	events.Subscribe(c.handleUserEvent)
+}
+
+// handleUserEvent จัดการเหตุการณ์ของผู้ใช้ที่เกี่ยวข้องกับห้องสนทนา
+func (c *ChatUsecase) handleUserEvent(event domain.UserEvent) {
+	switch event.Type {
+	case domain.UserCreated:
+		c.ChatRepo.Join(event.Username) // ผู้ใช้ใหม่เข้าร่วมห้องสนทนาหลัก
+	}
+}
+
+// SendMessage ส่งข้อความแชทในนามของ sender ซึ่งต้องมีสิทธิ์ PermChatSend
+func (c *ChatUsecase) SendMessage(sender *domain.User, message string) error {
+	if err := c.Authorizer.Authorize(sender, domain.PermChatSend, domain.Resource{Kind: domain.ResourceChat}); err != nil {
+		return err
+	}
+	c.ChatRepo.Send(domain.ChatMessage{
+		Sender:    sender.Username, // ชื่อผู้ส่ง
+		Message:   message,         // ข้อความที่ส่ง
+		TimeStamp: time.Now(),      // เวลาที่ส่งข้อความ
+	})
+	return nil
+}
+
+// Leave ให้ผู้ใช้ (username) ออกจากห้องสนทนา
+func (c *ChatUsecase) Leave(username string) {
+	c.ChatRepo.Leave(username)
+}
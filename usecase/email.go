@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"crypto/subtle"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NormalizeEmail ตรวจสอบรูปแบบอีเมลและคืนค่าอีเมลที่ตัดช่องว่างและแปลงเป็นตัวพิมพ์เล็ก
+// เพื่อใช้ตรวจสอบความซ้ำและค้นหาผู้ใช้
+func NormalizeEmail(email string) (string, bool) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" { // ต้องเป็นอีเมลล้วน ไม่มีชื่อแสดงผล
+		return "", false
+	}
+	if !strings.Contains(email[strings.LastIndex(email, "@"):], ".") { // โดเมนต้องมีจุดอย่างน้อยหนึ่งจุด
+		return "", false
+	}
+	return strings.ToLower(email), true
+}
+
+// SetEmail เปลี่ยนอีเมลของผู้ใช้ อีเมลใหม่จะยังไม่ถูกยืนยันจนกว่าจะเรียก VerifyEmail
+func (u *UserUsecase) SetEmail(actor *domain.User, userID int64, email string) error {
+	normalized, ok := NormalizeEmail(email)
+	if !ok {
+		return u.Constants.ErrInvalidEmail
+	}
+
+	user, err := u.UserRepo.GetByID(userID) // ดึงข้อมูลผู้ใช้ตาม ID
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserUpdate, domain.UserResource(user)); err != nil {
+		return err
+	}
+
+	changed := false
+	if _, err := u.modifyUser(user.ID, func(updated *domain.User) error {
+		previous := updated.Email
+		if err := u.applyEmail(updated, email); err != nil {
+			return err
+		}
+		if updated.Email == previous { // อีเมลเดิม ไม่ต้องเปลี่ยนแปลง
+			return errUnchanged
+		}
+		changed = true
+		return nil
+	}); err != nil {
+		return u.emailConflict(userID, normalized, err)
+	}
+
+	if changed {
+		u.Logger.Printf("Email changed: %s\n", user.Username) // บันทึกการเปลี่ยนอีเมลในล็อก
+	}
+	return nil
+}
+
+// applyEmail ตั้งอีเมลที่ normalize แล้วให้ user และล้างสถานะการยืนยัน หากอีเมลเปลี่ยนไปจากเดิม
+func (u *UserUsecase) applyEmail(user *domain.User, email string) error {
+	normalized, ok := NormalizeEmail(email)
+	if !ok {
+		return u.Constants.ErrInvalidEmail
+	}
+	if user.Email == normalized {
+		return nil
+	}
+	user.Email = normalized
+	user.EmailVerified = false // อีเมลใหม่ต้องยืนยันใหม่
+	user.EmailVerificationHash = ""
+	user.EmailVerificationExpiresAt = time.Time{}
+	return nil
+}
+
+// emailConflict แปลงข้อผิดพลาด err จากการบันทึกผู้ใช้ userID เป็น ErrEmailAlreadyExists หากอีเมลถูกใช้โดยผู้ใช้อื่นแล้ว
+func (u *UserUsecase) emailConflict(userID int64, email string, err error) error {
+	if email == "" {
+		return err
+	}
+	if owner, lookupErr := u.UserRepo.GetByEmail(email); lookupErr == nil && owner.ID != userID {
+		return u.Constants.ErrEmailAlreadyExists
+	}
+	return err
+}
+
+// RequestEmailVerification สร้างโทเคนยืนยันอีเมลแบบใช้ครั้งเดียวและส่งไปยังอีเมลของผู้ใช้ผ่าน Notifier
+// ผู้ดำเนินการต้องมีสิทธิ์ PermUserUpdate กับผู้ใช้ userID
+func (u *UserUsecase) RequestEmailVerification(actor *domain.User, userID int64) error {
+	if u.Notifier == nil {
+		return fmt.Errorf("email verification requires a notifier")
+	}
+
+	user, err := u.UserRepo.GetByID(userID)
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserUpdate, domain.UserResource(user)); err != nil {
+		return err
+	}
+
+	token, tokenHash, err := u.newUserToken(user.ID) // สร้างโทเคนที่ระบุผู้ใช้ได้
+	if err != nil {
+		return err
+	}
+
+	updated, err := u.modifyUser(user.ID, func(updated *domain.User) error {
+		if updated.Email == "" {
+			return u.Constants.ErrInvalidEmail // ผู้ใช้ยังไม่มีอีเมล
+		}
+		updated.EmailVerificationHash = tokenHash // เก็บเฉพาะแฮชของโทเคน
+		updated.EmailVerificationExpiresAt = time.Now().Add(u.Config.EmailVerificationTTL)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Use this token to verify your email address within %s:\n\n%s\n", u.Config.EmailVerificationTTL, token)
+	if err := u.Notifier.Send(updated.Email, "Verify your email address", body); err != nil {
+		return fmt.Errorf("error sending email verification: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail ยืนยันอีเมลของผู้ใช้ด้วยโทเคนที่ได้รับจาก RequestEmailVerification
+func (u *UserUsecase) VerifyEmail(token string) error {
+	userID, ok := parseUserToken(token)
+	if !ok {
+		return u.Constants.ErrInvalidEmailToken
+	}
+
+	if _, err := u.UserRepo.GetByID(userID); err != nil {
+		return u.Constants.ErrInvalidEmailToken
+	}
+	user, err := u.modifyUser(userID, func(updated *domain.User) error { // ตรวจสอบและใช้โทเคนภายใต้ล็อก เพื่อให้ใช้ได้เพียงครั้งเดียว
+		if updated.EmailVerificationHash == "" {
+			return u.Constants.ErrInvalidEmailToken
+		}
+		if subtle.ConstantTimeCompare([]byte(updated.EmailVerificationHash), []byte(hashSessionToken(token))) != 1 {
+			return u.Constants.ErrInvalidEmailToken
+		}
+		if time.Now().After(updated.EmailVerificationExpiresAt) { // โทเคนหมดอายุแล้ว
+			return u.Constants.ErrInvalidEmailToken
+		}
+		updated.EmailVerified = true
+		updated.EmailVerificationHash = "" // ใช้โทเคนแล้ว
+		updated.EmailVerificationExpiresAt = time.Time{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Email verified: %s\n", user.Username) // บันทึกการยืนยันอีเมลในล็อก
+	return nil
+}
+
+// findUser ค้นหาผู้ใช้จากชื่อผู้ใช้ หรือจากอีเมลที่ยืนยันแล้วหากข้อความที่ป้อนมีรูปแบบเป็นอีเมล
+// อีเมลที่ยังไม่ยืนยันใช้แทนชื่อผู้ใช้ไม่ได้ เพราะผู้ใช้อาจตั้งอีเมลของผู้อื่นไว้
+func (u *UserUsecase) findUser(identifier string) (*domain.User, error) {
+	username := identifier
+	if canonical, err := CanonicalUsername(identifier); err == nil { // ชื่อผู้ใช้ถูกเก็บในรูปแบบมาตรฐาน
+		username = canonical
+	}
+	user, err := u.UserRepo.GetByUsername(username)
+	if err == nil {
+		return user, nil
+	}
+	if email, ok := NormalizeEmail(identifier); ok { // ลองค้นหาจากอีเมล
+		user, emailErr := u.UserRepo.GetByEmail(email)
+		if emailErr != nil {
+			return nil, emailErr
+		}
+		if user.EmailVerified {
+			return user, nil
+		}
+	}
+	return nil, err
+}
+
+// recipientFor คืนค่าผู้รับการแจ้งเตือนของผู้ใช้ โดยใช้อีเมลเฉพาะเมื่อยืนยันแล้ว มิฉะนั้นใช้ชื่อผู้ใช้
+// อีเมลที่ยังไม่ยืนยันอาจเป็นของผู้อื่น จึงห้ามส่งข้อมูลลับ เช่น โทเคนรีเซ็ตรหัสผ่าน ไปยังอีเมลนั้น
+func recipientFor(user *domain.User) string {
+	if user.Email != "" && user.EmailVerified {
+		return user.Email
+	}
+	return user.Username
+}
+
+// newUserToken สร้างโทเคนแบบใช้ครั้งเดียวในรูปแบบ <userID>.<random> และคืนค่าแฮชสำหรับเก็บไว้
+func (u *UserUsecase) newUserToken(userID int64) (string, string, error) {
+	secret, err := generateSessionToken(u.Config.SessionTokenLength) // สร้างส่วนสุ่มของโทเคน
+	if err != nil {
+		return "", "", err
+	}
+	token := strconv.FormatInt(userID, 10) + "." + secret // ใส่ ID ของผู้ใช้ไว้ในโทเคนเพื่อค้นหาผู้ใช้ได้
+	return token, hashSessionToken(token), nil
+}
+
+// parseUserToken แยก ID ของผู้ใช้ออกจากโทเคนที่สร้างด้วย newUserToken
+func parseUserToken(token string) (int64, bool) {
+	id, _, found := strings.Cut(token, ".")
+	if !found {
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(id, 10, 64)
+	return userID, err == nil
+}
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRequestEmailVerificationRequiresAuthorization(t *testing.T) {
+	u := newTestUsecase(t)
+	u.Notifier = &recordingNotifier{}
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	bob := mustCreateUser(t, u, "bobby", u.Constants.RoleUser)
+	if err := u.SetEmail(alice, alice.ID, "alice@example.com"); err != nil {
+		t.Fatalf("SetEmail error = %v", err)
+	}
+
+	if err := u.RequestEmailVerification(bob, alice.ID); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("RequestEmailVerification by other user error = %v, want ErrPermissionDenied", err)
+	}
+	if err := u.RequestEmailVerification(nil, alice.ID); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("RequestEmailVerification without actor error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestLoginByEmailRequiresVerification(t *testing.T) {
+	u := newTestUsecase(t)
+	notifier := &recordingNotifier{}
+	u.Notifier = notifier
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	if err := u.SetEmail(alice, alice.ID, "alice@example.com"); err != nil {
+		t.Fatalf("SetEmail error = %v", err)
+	}
+
+	if _, err := u.Login("alice@example.com", testPassword); !errors.Is(err, u.Constants.ErrUserNotFound) {
+		t.Fatalf("Login with unverified email error = %v, want ErrUserNotFound", err)
+	}
+
+	if err := u.RequestEmailVerification(alice, alice.ID); err != nil {
+		t.Fatalf("RequestEmailVerification error = %v", err)
+	}
+	if err := u.VerifyEmail(notifier.lastToken(t)); err != nil {
+		t.Fatalf("VerifyEmail error = %v", err)
+	}
+	if _, err := u.Login("Alice@Example.com", testPassword); err != nil {
+		t.Errorf("Login with verified email error = %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"slices"
+	"sort"
+)
+
+// GroupRepository interface สำหรับจัดเก็บกลุ่มของผู้ใช้
+type GroupRepository interface {
+	Create(group *domain.Group) error                  // สร้างกลุ่มใหม่และกำหนด ID ให้กลุ่ม
+	GetByID(id int64) (*domain.Group, error)           // ดึงกลุ่มตามรหัสประจำตัว
+	GetAll() ([]*domain.Group, error)                  // ดึงกลุ่มทั้งหมด
+	GetByMember(userID int64) ([]*domain.Group, error) // ดึงกลุ่มทั้งหมดที่ผู้ใช้เป็นสมาชิก
+	Update(group *domain.Group) error                  // ปรับปรุงข้อมูลกลุ่ม
+	Delete(id int64) error                             // ลบกลุ่มตามรหัสประจำตัว
+}
+
+// CreateGroup สร้างกลุ่มใหม่ที่ยังไม่มีสมาชิก
+func (u *UserUsecase) CreateGroup(actor *domain.User, name string) (*domain.Group, error) {
+	if err := u.Authorize(actor, domain.PermGroupManage, domain.Resource{Kind: domain.ResourceGroup}); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, u.Constants.ErrInvalidGroup
+	}
+
+	groups, err := u.GroupRepo.GetAll() // ตรวจสอบว่ามีกลุ่มชื่อนี้อยู่แล้วหรือไม่
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Name == name {
+			return nil, u.Constants.ErrGroupExists
+		}
+	}
+
+	group := &domain.Group{Name: name}
+	if err := u.GroupRepo.Create(group); err != nil {
+		return nil, err
+	}
+
+	u.Logger.Printf("Group created by %s: %s\n", actor.Username, name) // บันทึกการสร้างกลุ่มในล็อก
+	return group, nil
+}
+
+// DeleteGroup ลบกลุ่ม สมาชิกจะไม่ได้รับบทบาทและสิทธิ์ของกลุ่มอีกต่อไป
+func (u *UserUsecase) DeleteGroup(actor *domain.User, groupID int64) error {
+	group, err := u.getGroup(actor, groupID)
+	if err != nil {
+		return err
+	}
+	if err := u.GroupRepo.Delete(groupID); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Group deleted by %s: %s\n", actor.Username, group.Name) // บันทึกการลบกลุ่มในล็อก
+	return nil
+}
+
+// AddGroupMember เพิ่มผู้ใช้ userID เป็นสมาชิกของกลุ่ม หากกลุ่มมีบทบาทหรือสิทธิ์ ผู้ดำเนินการต้องมอบสิ่งเหล่านั้นได้ตาม SetGroupGrants
+func (u *UserUsecase) AddGroupMember(actor *domain.User, groupID, userID int64) error {
+	group, err := u.getGroup(actor, groupID)
+	if err != nil {
+		return err
+	}
+	user, err := u.UserRepo.GetByID(userID) // สมาชิกต้องเป็นผู้ใช้ที่มีอยู่จริง
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if group.HasMember(userID) { // เป็นสมาชิกอยู่แล้ว ไม่ต้องเปลี่ยนแปลง
+		return nil
+	}
+	if err := u.authorizeGrant(actor, domain.UserResource(user), group.Roles, group.Permissions); err != nil { // สมาชิกใหม่จะได้รับบทบาทและสิทธิ์ของกลุ่ม
+		return err
+	}
+
+	updated := *group
+	updated.MemberIDs = append(slices.Clone(group.MemberIDs), userID)
+	if err := u.GroupRepo.Update(&updated); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Group member added by %s: %s joined %s\n", actor.Username, user.Username, group.Name) // บันทึกการเพิ่มสมาชิกในล็อก
+	return nil
+}
+
+// RemoveGroupMember นำผู้ใช้ userID ออกจากกลุ่ม
+func (u *UserUsecase) RemoveGroupMember(actor *domain.User, groupID, userID int64) error {
+	group, err := u.getGroup(actor, groupID)
+	if err != nil {
+		return err
+	}
+	if !group.HasMember(userID) {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.removeMember(group, userID); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Group member removed by %s: user ID %d left %s\n", actor.Username, userID, group.Name) // บันทึกการนำสมาชิกออกในล็อก
+	return nil
+}
+
+// SetGroupGrants กำหนดบทบาทและสิทธิ์ของกลุ่มแทนค่าเดิมทั้งหมด สมาชิกทุกคนจะได้รับบทบาทและสิทธิ์เหล่านี้เพิ่มจากบทบาทของตนเอง
+// การกำหนดบทบาทหรือสิทธิ์ให้กลุ่มต้องมีสิทธิ์ PermRoleAssign และผู้ดำเนินการต้องมีสิทธิ์ทุกข้อที่มอบอยู่แล้ว
+func (u *UserUsecase) SetGroupGrants(actor *domain.User, groupID int64, roles []string, permissions []domain.Permission) error {
+	group, err := u.getGroup(actor, groupID)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if !u.IsValidRole(role) {
+			return u.Constants.ErrRoleNotFound
+		}
+	}
+	for _, permission := range permissions {
+		if !slices.Contains(domain.AllPermissions, permission) {
+			return u.Constants.ErrInvalidGroup
+		}
+	}
+	if err := u.authorizeGrant(actor, domain.Resource{Kind: domain.ResourceGroup, ID: groupID}, roles, permissions); err != nil {
+		return err
+	}
+
+	updated := *group
+	updated.Roles = slices.Clone(roles)
+	updated.Permissions = slices.Clone(permissions)
+	if err := u.GroupRepo.Update(&updated); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Group grants changed by %s: %s\n", actor.Username, group.Name) // บันทึกการเปลี่ยนบทบาทและสิทธิ์ของกลุ่มในล็อก
+	return nil
+}
+
+// ListGroups คืนค่ากลุ่มทั้งหมดในระบบ
+func (u *UserUsecase) ListGroups() ([]*domain.Group, error) {
+	return u.GroupRepo.GetAll()
+}
+
+// EffectivePermissions คืนค่าสิทธิ์ทั้งหมดของผู้ใช้ ซึ่งรวมจากบทบาทของผู้ใช้ (รวมการสืบทอด) และบทบาทและสิทธิ์ของทุกกลุ่มที่เป็นสมาชิก
+func (u *UserUsecase) EffectivePermissions(userID int64) (*domain.EffectivePermissions, error) {
+	user, err := u.UserRepo.GetByID(userID) // ดึงข้อมูลผู้ใช้ตาม ID
+	if err != nil {
+		return nil, u.Constants.ErrUserNotFound
+	}
+
+	permissions, ownPermissions := u.collectPermissions(user)
+	effective := &domain.EffectivePermissions{
+		Permissions:    sortedPermissions(permissions),
+		OwnPermissions: make([]domain.Permission, 0, len(ownPermissions)),
+	}
+	for _, permission := range sortedPermissions(ownPermissions) {
+		if !permissions[permission] { // สิทธิ์ที่ใช้ได้กับทุก resource อยู่แล้วไม่ต้องแสดงซ้ำ
+			effective.OwnPermissions = append(effective.OwnPermissions, permission)
+		}
+	}
+	return effective, nil
+}
+
+// getGroup ตรวจสอบสิทธิ์ PermGroupManage และดึงกลุ่มตามรหัสประจำตัว
+func (u *UserUsecase) getGroup(actor *domain.User, groupID int64) (*domain.Group, error) {
+	if err := u.Authorize(actor, domain.PermGroupManage, domain.Resource{Kind: domain.ResourceGroup, ID: groupID}); err != nil {
+		return nil, err
+	}
+	group, err := u.GroupRepo.GetByID(groupID)
+	if err != nil {
+		return nil, u.Constants.ErrGroupNotFound
+	}
+	return group, nil
+}
+
+// removeMember นำผู้ใช้ userID ออกจากกลุ่มและบันทึกผ่าน GroupRepo
+func (u *UserUsecase) removeMember(group *domain.Group, userID int64) error {
+	updated := *group
+	updated.MemberIDs = slices.DeleteFunc(slices.Clone(group.MemberIDs), func(id int64) bool { return id == userID })
+	return u.GroupRepo.Update(&updated)
+}
+
+// sortedPermissions แปลงชุดสิทธิ์เป็น slice ที่เรียงตามชื่อสิทธิ์
+func sortedPermissions(set map[domain.Permission]bool) []domain.Permission {
+	permissions := make([]domain.Permission, 0, len(set))
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"time"
+)
+
+// isLoginBlocked ตรวจสอบว่าบัญชีถูกล็อกอยู่ หรือยังไม่ครบเวลารอแบบ exponential backoff หลังเข้าสู่ระบบไม่สำเร็จ
+func (u *UserUsecase) isLoginBlocked(user *domain.User, now time.Time) bool {
+	if now.Before(user.LockedUntil) { // บัญชียังอยู่ในช่วงเวลาล็อก
+		return true
+	}
+	if user.FailedLoginAttempts == 0 {
+		return false
+	}
+	return now.Before(user.LastFailedLoginAt.Add(u.loginBackoff(user.FailedLoginAttempts))) // ยังไม่ครบเวลารอ
+}
+
+// beginLoginAttempt ตรวจสอบการล็อกบัญชีและจองการพยายามยืนยันตัวตนครั้งนี้ภายใต้ล็อกของผู้ใช้ ก่อนตรวจสอบรหัสผ่านหรือรหัส TOTP
+// การพยายามถูกนับเป็นไม่สำเร็จไว้ก่อน และถูกล้างด้วย resetFailedLogins เมื่อยืนยันตัวตนสำเร็จ
+// การเดารหัสพร้อมกันหลายครั้งจึงเห็นตัวนับที่เพิ่มขึ้นแล้ว และไม่สามารถข้าม backoff หรือการล็อกบัญชีได้
+// คืนค่าข้อมูลล่าสุดของผู้ใช้ หรือ ErrAccountLocked หากบัญชีถูกล็อกหรือยังไม่ครบเวลารอ
+func (u *UserUsecase) beginLoginAttempt(user *domain.User, now time.Time) (*domain.User, error) {
+	return u.modifyUser(user.ID, func(updated *domain.User) error {
+		if u.isLoginBlocked(updated, now) { // บัญชีถูกล็อก หรือยังไม่ครบเวลารอหลังจากยืนยันตัวตนไม่สำเร็จ
+			return u.Constants.ErrAccountLocked
+		}
+
+		updated.FailedLoginAttempts++   // เพิ่มจำนวนครั้งที่ไม่สำเร็จไว้ก่อน
+		updated.LastFailedLoginAt = now // บันทึกเวลาที่พยายามล่าสุด
+
+		if threshold := u.Config.MaxFailedLogins; threshold > 0 && updated.FailedLoginAttempts%threshold == 0 {
+			updated.LockedUntil = now.Add(u.lockoutDuration(updated.FailedLoginAttempts / threshold)) // ล็อกบัญชี
+			u.Logger.Printf("Account locked until %s: %s\n", updated.LockedUntil.Format("2006-01-02 15:04:05"), user.Username)
+		}
+		return nil
+	})
+}
+
+// resetFailedLogins ล้างสถานะการเข้าสู่ระบบไม่สำเร็จหลังจากเข้าสู่ระบบสำเร็จ
+func (u *UserUsecase) resetFailedLogins(user *domain.User) *domain.User {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil.IsZero() { // ไม่มีสถานะที่ต้องล้าง
+		return user
+	}
+
+	updated, err := u.modifyUser(user.ID, func(updated *domain.User) error {
+		if updated.FailedLoginAttempts == 0 && updated.LockedUntil.IsZero() { // ถูกล้างไปแล้วระหว่างรอล็อก
+			return errUnchanged
+		}
+		updated.FailedLoginAttempts = 0
+		updated.LastFailedLoginAt = time.Time{}
+		updated.LockedUntil = time.Time{}
+		return nil
+	})
+	if err != nil {
+		u.Logger.Printf("Failed to reset failed logins for %s: %v\n", user.Username, err)
+		return user
+	}
+	return updated
+}
+
+// UnlockAccount ให้ผู้ที่มีสิทธิ์ PermUserUnlock ปลดล็อกบัญชีและล้างจำนวนครั้งที่เข้าสู่ระบบไม่สำเร็จ
+func (u *UserUsecase) UnlockAccount(actor *domain.User, targetID int64) error {
+	target, err := u.UserRepo.GetByID(targetID) // ดึงข้อมูลผู้ใช้ที่ต้องการปลดล็อก
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserUnlock, domain.UserResource(target)); err != nil {
+		return err
+	}
+
+	u.resetFailedLogins(target)
+	u.Logger.Printf("Account unlocked by %s: %s\n", actor.Username, target.Username) // บันทึกการปลดล็อกในล็อก
+	return nil
+}
+
+// loginBackoff คำนวณเวลารอหลังจากเข้าสู่ระบบไม่สำเร็จ attempts ครั้ง โดยเพิ่มเป็นสองเท่าในแต่ละครั้ง
+func (u *UserUsecase) loginBackoff(attempts int) time.Duration {
+	return doubling(u.Config.LoginBackoffBase, attempts-1, u.Config.LoginBackoffMax)
+}
+
+// lockoutDuration คำนวณระยะเวลาล็อกบัญชีสำหรับการล็อกครั้งที่ lockouts
+func (u *UserUsecase) lockoutDuration(lockouts int) time.Duration {
+	return doubling(u.Config.LockoutDuration, lockouts-1, u.Config.MaxLockout)
+}
+
+// doubling คืนค่า base คูณ 2 ยกกำลัง exponent โดยไม่เกิน limit (limit เป็น 0 คือไม่จำกัด)
+func doubling(base time.Duration, exponent int, limit time.Duration) time.Duration {
+	duration := base
+	for i := 0; i < exponent; i++ {
+		if limit > 0 && duration >= limit {
+			break
+		}
+		duration *= 2
+	}
+	if limit > 0 && duration > limit {
+		return limit
+	}
+	return duration
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBeginLoginAttemptConcurrent(t *testing.T) {
+	u := newTestUsecase(t)
+	u.Config.MaxFailedLogins = 0  // ไม่ล็อกบัญชี เพื่อตรวจสอบเฉพาะตัวนับ
+	u.Config.LoginBackoffBase = 0 // ไม่ต้องรอระหว่างการพยายามแต่ละครั้ง
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	const attempts = 50
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := u.beginLoginAttempt(alice, time.Now()); err != nil { // ทุก goroutine ใช้ข้อมูลผู้ใช้ชุดเดิม (stale) เหมือนการเข้าสู่ระบบพร้อมกัน
+				t.Errorf("beginLoginAttempt error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := mustGetUser(t, u, alice.ID).FailedLoginAttempts; got != attempts {
+		t.Errorf("FailedLoginAttempts = %d, want %d", got, attempts)
+	}
+}
+
+func TestLoginLocksAccountAfterMaxFailures(t *testing.T) {
+	u := newTestUsecase(t)
+	u.Config.LoginBackoffBase = 0 // ไม่ต้องรอระหว่างการเข้าสู่ระบบแต่ละครั้ง
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	for i := 0; i < u.Config.MaxFailedLogins; i++ {
+		if _, err := u.Login("alice", "wrong-password"); !errors.Is(err, u.Constants.ErrInvalidPassword) {
+			t.Fatalf("Login attempt %d error = %v, want ErrInvalidPassword", i+1, err)
+		}
+	}
+	if _, err := u.Login("alice", testPassword); !errors.Is(err, u.Constants.ErrAccountLocked) {
+		t.Fatalf("Login after lockout error = %v, want ErrAccountLocked", err)
+	}
+
+	if err := u.UnlockAccount(admin, alice.ID); err != nil {
+		t.Fatalf("UnlockAccount error = %v", err)
+	}
+	if _, err := u.Login("alice", testPassword); err != nil {
+		t.Fatalf("Login after unlock error = %v", err)
+	}
+	if got := mustGetUser(t, u, alice.ID).FailedLoginAttempts; got != 0 {
+		t.Errorf("FailedLoginAttempts after login = %d, want 0", got)
+	}
+}
+
+func TestLoginBackoffBlocksImmediateRetry(t *testing.T) {
+	u := newTestUsecase(t)
+	mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	if _, err := u.Login("alice", "wrong-password"); !errors.Is(err, u.Constants.ErrInvalidPassword) {
+		t.Fatalf("Login error = %v, want ErrInvalidPassword", err)
+	}
+	if _, err := u.Login("alice", testPassword); !errors.Is(err, u.Constants.ErrAccountLocked) {
+		t.Errorf("immediate retry error = %v, want ErrAccountLocked", err)
+	}
+}
+
+func TestUserMutationsKeepFailedLoginCount(t *testing.T) {
+	u := newTestUsecase(t)
+	u.Config.MaxFailedLogins = 0  // ไม่ล็อกบัญชี เพื่อตรวจสอบเฉพาะตัวนับ
+	u.Config.LoginBackoffBase = 0 // ไม่ต้องรอระหว่างการพยายามแต่ละครั้ง
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := u.beginLoginAttempt(alice, time.Now()); err != nil {
+				t.Errorf("beginLoginAttempt error = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := u.ResetPassword(admin, alice.ID); err != nil { // แฮชรหัสผ่านระหว่างอ่านและบันทึก ต้องไม่เขียนทับตัวนับ
+				t.Errorf("ResetPassword error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := mustGetUser(t, u, alice.ID).FailedLoginAttempts; got != attempts {
+		t.Errorf("FailedLoginAttempts = %d, want %d", got, attempts)
+	}
+}
+
+func TestConcurrentLoginGuessesRespectBackoff(t *testing.T) {
+	u := newTestUsecase(t)
+	mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	var invalid atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ { // เดารหัสผ่านพร้อมกัน ต้องมีเพียงครั้งเดียวที่ได้ตรวจสอบรหัสผ่านก่อนต้องรอ backoff
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := u.Login("alice", "wrong-password")
+			switch {
+			case errors.Is(err, u.Constants.ErrInvalidPassword):
+				invalid.Add(1)
+			case !errors.Is(err, u.Constants.ErrAccountLocked):
+				t.Errorf("Login error = %v, want ErrInvalidPassword or ErrAccountLocked", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := invalid.Load(); got != 1 {
+		t.Errorf("password checks = %d, want 1", got)
+	}
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2idPrefix คำนำหน้าของแฮชรหัสผ่านในรูปแบบ PHC ที่ใช้ argon2id
+const argon2idPrefix = "$argon2id$"
+
+// errInvalidHashFormat ข้อผิดพลาดเมื่อแฮชที่เก็บไว้ไม่อยู่ในรูปแบบ PHC ที่ถูกต้อง
+var errInvalidHashFormat = errors.New("invalid password hash format")
+
+// passwordHash แทนแฮชรหัสผ่านที่ถอดรหัสจากรูปแบบ PHC พร้อมพารามิเตอร์ของ argon2
+type passwordHash struct {
+	Algorithm string // ชื่ออัลกอริทึม เช่น "argon2id"
+	Version   int    // เวอร์ชันของ argon2
+	Memory    uint32 // จำนวนหน่วยความจำใน kilobytes (m)
+	Time      uint32 // จำนวนรอบการเข้ารหัส (t)
+	Threads   uint8  // จำนวนเธรด (p)
+	Salt      []byte // salt ที่ใช้ในการแฮช
+	Key       []byte // ผลลัพธ์ของการแฮช
+}
+
+// encodePasswordHash เข้ารหัสแฮชให้อยู่ในรูปแบบ $argon2id$v=19$m=...,t=...,p=...$salt$hash
+func encodePasswordHash(h *passwordHash) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		h.Algorithm, h.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(h.Salt),
+		base64.RawStdEncoding.EncodeToString(h.Key),
+	)
+}
+
+// decodePasswordHash ถอดรหัสแฮชในรูปแบบ PHC และคืนค่าพารามิเตอร์ที่ใช้ตอนแฮช
+func decodePasswordHash(encoded string) (*passwordHash, error) {
+	parts := strings.Split(encoded, "$") // แยกส่วนต่างๆ ของแฮช โดยส่วนแรกเป็นข้อความว่าง
+	if len(parts) != 6 || parts[0] != "" {
+		return nil, errInvalidHashFormat
+	}
+
+	h := &passwordHash{Algorithm: parts[1]}
+	if h.Algorithm != "argon2id" { // รองรับเฉพาะ argon2id
+		return nil, fmt.Errorf("unsupported password hash algorithm: %s", h.Algorithm)
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &h.Version); err != nil {
+		return nil, errInvalidHashFormat
+	}
+	if h.Version != argon2.Version { // เวอร์ชันต้องตรงกับที่ไลบรารีรองรับ
+		return nil, fmt.Errorf("unsupported argon2 version: %d", h.Version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.Memory, &h.Time, &h.Threads); err != nil {
+		return nil, errInvalidHashFormat
+	}
+	if h.Time < 1 || h.Threads < 1 || h.Memory < 8*uint32(h.Threads) { // argon2.IDKey จะ panic เมื่อ t หรือ p เป็น 0 และ RFC 9106 กำหนด m อย่างน้อย 8*p
+		return nil, errInvalidHashFormat
+	}
+
+	var err error
+	if h.Salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errInvalidHashFormat
+	}
+	if h.Key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, errInvalidHashFormat
+	}
+	if len(h.Salt) == 0 || len(h.Key) == 0 { // key ว่างทำให้รหัสผ่านใดๆ ก็ตรวจสอบผ่าน
+		return nil, errInvalidHashFormat
+	}
+	return h, nil
+}
+
+// isPHCHash ตรวจสอบว่าแฮชที่เก็บไว้อยู่ในรูปแบบ PHC หรือเป็นรูปแบบเดิม (base64 ล้วนพร้อม salt แยก)
+func isPHCHash(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+// NeedsRehash ตรวจสอบว่าแฮชที่เก็บไว้ใช้อัลกอริทึมเดิมหรือพารามิเตอร์ที่อ่อนกว่าค่าปัจจุบันใน config หรือไม่
+func NeedsRehash(hashedPassword string, config *Config) bool {
+	if !isPHCHash(hashedPassword) { // แฮชรูปแบบเดิมต้องแฮชใหม่เสมอ
+		return true
+	}
+
+	stored, err := decodePasswordHash(hashedPassword)
+	if err != nil {
+		return true // แฮชที่อ่านไม่ได้ควรถูกแทนที่
+	}
+	return stored.Memory < config.ArgonMemory || // ใช้หน่วยความจำน้อยกว่าค่าปัจจุบัน
+		stored.Time < config.ArgonTime || // จำนวนรอบน้อยกว่าค่าปัจจุบัน
+		stored.Threads < config.ArgonThreads || // จำนวนเธรดน้อยกว่าค่าปัจจุบัน
+		len(stored.Key) < int(config.ArgonKeyLen) || // ความยาวของ key สั้นกว่าค่าปัจจุบัน
+		len(stored.Salt) < config.SaltLength // ความยาวของ salt สั้นกว่าค่าปัจจุบัน
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestDecodePasswordHashRejectsDegenerateParameters(t *testing.T) {
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	key := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	tests := map[string]string{
+		"zero time":    "$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + key,
+		"zero threads": "$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + key,
+		"low memory":   "$argon2id$v=19$m=7,t=1,p=1$" + salt + "$" + key,
+		"memory < 8p":  "$argon2id$v=19$m=16,t=1,p=4$" + salt + "$" + key,
+		"empty salt":   "$argon2id$v=19$m=1024,t=1,p=1$$" + key,
+		"empty key":    "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$",
+	}
+	for name, encoded := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodePasswordHash(encoded); !errors.Is(err, errInvalidHashFormat) {
+				t.Errorf("decodePasswordHash error = %v, want errInvalidHashFormat", err)
+			}
+			if ValidatePassword("anything", encoded, nil, DefaultConfig()) { // ต้องไม่ panic และไม่ยอมรับรหัสผ่าน
+				t.Error("ValidatePassword accepted a degenerate hash")
+			}
+		})
+	}
+}
+
+func TestLoginRehashesLegacyPassword(t *testing.T) {
+	u := newTestUsecase(t)
+	salt := []byte("legacy-salt-1234")
+	legacy := argon2.IDKey([]byte(testPassword), salt, u.Config.ArgonTime, u.Config.ArgonMemory, u.Config.ArgonThreads, u.Config.ArgonKeyLen)
+	user := &domain.User{
+		Username: "alice",
+		Role:     u.Constants.RoleUser,
+		Status:   domain.StatusActive,
+		Password: base64.RawStdEncoding.EncodeToString(legacy), // แฮชรูปแบบเดิม base64 ล้วนพร้อม salt แยก
+		Salt:     salt,
+	}
+	if err := u.UserRepo.Create(user); err != nil {
+		t.Fatalf("Create error = %v", err)
+	}
+
+	if _, err := u.Login("alice", testPassword); err != nil {
+		t.Fatalf("Login error = %v", err)
+	}
+	stored := mustGetUser(t, u, user.ID)
+	if !isPHCHash(stored.Password) || stored.Salt != nil {
+		t.Fatalf("stored hash = %q salt = %v, want PHC hash without separate salt", stored.Password, stored.Salt)
+	}
+	if NeedsRehash(stored.Password, u.Config) {
+		t.Error("rehashed password still needs rehash")
+	}
+	if _, err := u.Login("alice", testPassword); err != nil {
+		t.Errorf("Login after rehash error = %v", err)
+	}
+}
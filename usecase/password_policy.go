@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ชื่อกฎของนโยบายรหัสผ่าน ใช้ระบุว่ารหัสผ่านผิดกฎข้อใด
+const (
+	RuleMinLength        = "min_length"        // รหัสผ่านสั้นเกินไป
+	RuleMaxLength        = "max_length"        // รหัสผ่านยาวเกินไป
+	RuleUppercase        = "uppercase"         // ต้องมีตัวพิมพ์ใหญ่
+	RuleLowercase        = "lowercase"         // ต้องมีตัวพิมพ์เล็ก
+	RuleDigit            = "digit"             // ต้องมีตัวเลข
+	RuleSymbol           = "symbol"            // ต้องมีสัญลักษณ์
+	RuleContainsUsername = "contains_username" // รหัสผ่านมีชื่อผู้ใช้อยู่
+	RuleRepeatedChars    = "repeated_chars"    // มีตัวอักษรซ้ำติดกันมากเกินไป
+	RuleCommonPassword   = "common_password"   // เป็นรหัสผ่านที่พบบ่อยหรือรั่วไหล
+)
+
+// PasswordPolicy ตรวจสอบรหัสผ่านก่อนนำไปแฮชและบันทึก
+type PasswordPolicy interface {
+	Validate(username, password string) error // คืนค่า *PasswordPolicyError หากรหัสผ่านผิดกฎ
+}
+
+// PolicyViolation แทนกฎหนึ่งข้อที่รหัสผ่านไม่ผ่าน
+type PolicyViolation struct {
+	Rule    string // ชื่อกฎ เช่น RuleMinLength
+	Message string // คำอธิบายสำหรับผู้ใช้
+}
+
+// PasswordPolicyError รวมกฎทั้งหมดที่รหัสผ่านไม่ผ่าน
+type PasswordPolicyError struct {
+	Violations []PolicyViolation // รายการกฎที่ไม่ผ่าน
+}
+
+// Error คืนค่าข้อความที่รวมคำอธิบายของทุกกฎที่ไม่ผ่าน
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// HasRule ตรวจสอบว่ารหัสผ่านผิดกฎที่ระบุหรือไม่
+func (e *PasswordPolicyError) HasRule(rule string) bool {
+	for _, violation := range e.Violations {
+		if violation.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// RulePasswordPolicy นโยบายรหัสผ่านที่กำหนดกฎต่างๆ ได้
+type RulePasswordPolicy struct {
+	MinLength        int                 // จำนวนตัวอักษรขั้นต่ำ (นับเป็น rune)
+	MaxLength        int                 // จำนวนตัวอักษรสูงสุด (0 คือไม่จำกัด)
+	RequireUpper     bool                // ต้องมีตัวพิมพ์ใหญ่อย่างน้อยหนึ่งตัว
+	RequireLower     bool                // ต้องมีตัวพิมพ์เล็กอย่างน้อยหนึ่งตัว
+	RequireDigit     bool                // ต้องมีตัวเลขอย่างน้อยหนึ่งตัว
+	RequireSymbol    bool                // ต้องมีสัญลักษณ์อย่างน้อยหนึ่งตัว
+	DisallowUsername bool                // ห้ามมีชื่อผู้ใช้อยู่ในรหัสผ่าน
+	MaxRepeatedChars int                 // จำนวนตัวอักษรเดียวกันที่ซ้ำติดกันได้สูงสุด (0 คือไม่จำกัด)
+	CommonPasswords  map[string]struct{} // รายการรหัสผ่านที่พบบ่อยหรือรั่วไหล (ตัวพิมพ์เล็ก)
+}
+
+// NewDefaultPasswordPolicy สร้างนโยบายรหัสผ่านพื้นฐาน
+func NewDefaultPasswordPolicy() *RulePasswordPolicy {
+	policy := &RulePasswordPolicy{
+		MinLength:        8,    // อย่างน้อย 8 ตัวอักษร
+		MaxLength:        128,  // ไม่เกิน 128 ตัวอักษร
+		DisallowUsername: true, // ห้ามมีชื่อผู้ใช้อยู่ในรหัสผ่าน
+		MaxRepeatedChars: 3,    // ตัวอักษรเดียวกันซ้ำติดกันได้ไม่เกิน 3 ตัว
+		CommonPasswords:  make(map[string]struct{}),
+	}
+	policy.AddCommonPasswords("password", "12345678", "123456789", "qwerty123", "11111111", "password1", "iloveyou", "admin123")
+	return policy
+}
+
+// AddCommonPasswords เพิ่มรหัสผ่านที่ไม่อนุญาตให้ใช้
+func (p *RulePasswordPolicy) AddCommonPasswords(passwords ...string) {
+	if p.CommonPasswords == nil {
+		p.CommonPasswords = make(map[string]struct{}, len(passwords))
+	}
+	for _, password := range passwords {
+		p.CommonPasswords[strings.ToLower(password)] = struct{}{}
+	}
+}
+
+// LoadCommonPasswords โหลดรายการรหัสผ่านที่ไม่อนุญาตจากไฟล์ บรรทัดละหนึ่งรหัสผ่าน (บรรทัดที่ขึ้นต้นด้วย # ถือเป็นคอมเมนต์)
+func (p *RulePasswordPolicy) LoadCommonPasswords(path string) error {
+	file, err := os.Open(path) // เปิดไฟล์รายการรหัสผ่าน
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") { // ข้ามบรรทัดว่างและคอมเมนต์
+			continue
+		}
+		p.AddCommonPasswords(line)
+	}
+	return scanner.Err()
+}
+
+// Validate ตรวจสอบรหัสผ่านกับทุกกฎ และคืนค่า *PasswordPolicyError ที่รวมทุกกฎที่ไม่ผ่าน
+func (p *RulePasswordPolicy) Validate(username, password string) error {
+	var violations []PolicyViolation
+	addViolation := func(rule, format string, args ...any) {
+		violations = append(violations, PolicyViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password) // นับความยาวเป็นจำนวนตัวอักษร ไม่ใช่ bytes
+	if length < p.MinLength {
+		addViolation(RuleMinLength, "password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		addViolation(RuleMaxLength, "password must be at most %d characters long", p.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password { // ตรวจสอบชนิดของตัวอักษรแต่ละตัว
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		addViolation(RuleUppercase, "password must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		addViolation(RuleLowercase, "password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		addViolation(RuleDigit, "password must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		addViolation(RuleSymbol, "password must contain a symbol")
+	}
+
+	if p.DisallowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		addViolation(RuleContainsUsername, "password must not contain the username")
+	}
+	if p.MaxRepeatedChars > 0 && maxRepeatedRun(password) > p.MaxRepeatedChars {
+		addViolation(RuleRepeatedChars, "password must not repeat the same character more than %d times in a row", p.MaxRepeatedChars)
+	}
+	if _, exists := p.CommonPasswords[strings.ToLower(password)]; exists {
+		addViolation(RuleCommonPassword, "password is too common")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// maxRepeatedRun คืนค่าจำนวนตัวอักษรเดียวกันที่ซ้ำติดกันมากที่สุดในข้อความ
+func maxRepeatedRun(s string) int {
+	longest, current := 0, 0
+	var previous rune = -1
+	for _, r := range s {
+		if r == previous {
+			current++
+		} else {
+			current = 1
+			previous = r
+		}
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
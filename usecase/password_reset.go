@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Notifier ส่งข้อความถึงผู้ใช้ผ่านช่องทางภายนอก เช่น อีเมลหรือไฟล์
+type Notifier interface {
+	Send(recipient, subject, body string) error // ส่งข้อความถึงผู้รับ
+}
+
+// RequestPasswordReset สร้างโทเคนรีเซ็ตรหัสผ่านแบบใช้ครั้งเดียวและส่งให้ผู้ใช้ผ่าน Notifier
+// username อาจเป็นชื่อผู้ใช้หรืออีเมลก็ได้ หากไม่พบผู้ใช้จะคืนค่า nil เหมือนกรณีสำเร็จ เพื่อไม่ให้เปิดเผยว่ามีผู้ใช้นี้หรือไม่
+func (u *UserUsecase) RequestPasswordReset(username string) error {
+	if u.Notifier == nil {
+		return errors.New("password reset requires a notifier")
+	}
+
+	user, err := u.findUser(username) // ดึงข้อมูลผู้ใช้ตามชื่อผู้ใช้หรืออีเมล
+	if err != nil {
+		u.Logger.Printf("Password reset requested for unknown user: %s\n", username)
+		return nil
+	}
+
+	token, tokenHash, err := u.newUserToken(user.ID) // สร้างโทเคนที่ระบุผู้ใช้ได้
+	if err != nil {
+		return err
+	}
+
+	if _, err := u.modifyUser(user.ID, func(updated *domain.User) error {
+		updated.ResetTokenHash = tokenHash // เก็บเฉพาะแฮชของโทเคน
+		updated.ResetTokenExpiresAt = time.Now().Add(u.Config.PasswordResetTTL)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Use this token to reset your password within %s:\n\n%s\n\nIf you did not request a password reset, you can ignore this message.\n", u.Config.PasswordResetTTL, token)
+	if err := u.Notifier.Send(recipientFor(user), "Password reset", body); err != nil {
+		return fmt.Errorf("error sending password reset: %w", err)
+	}
+
+	u.Logger.Printf("Password reset requested: %s\n", user.Username) // บันทึกการขอรีเซ็ตรหัสผ่านในล็อก
+	return nil
+}
+
+// CompleteReset ตั้งรหัสผ่านใหม่ด้วยโทเคนรีเซ็ตรหัสผ่าน โทเคนจะใช้ได้เพียงครั้งเดียว
+// และเซสชันเดิมทั้งหมดของผู้ใช้จะถูกยกเลิก
+func (u *UserUsecase) CompleteReset(token, newPassword string) error {
+	userID, ok := parseUserToken(token) // แยก ID ของผู้ใช้ออกจากโทเคน
+	if !ok {
+		return u.Constants.ErrInvalidResetToken
+	}
+
+	user, err := u.UserRepo.GetByID(userID)
+	if err != nil {
+		return u.Constants.ErrInvalidResetToken
+	}
+	if err := u.checkResetToken(user, token); err != nil { // ตรวจสอบก่อนแฮชรหัสผ่านใหม่ เพื่อไม่ให้โทเคนผิดใช้เวลาแฮช
+		return err
+	}
+	if err := u.checkPasswordPolicy(user.Username, newPassword); err != nil { // ตรวจสอบรหัสผ่านใหม่ตามนโยบาย
+		return err
+	}
+
+	if _, err := u.setPassword(user.ID, newPassword, false, func(updated *domain.User) error { // แฮชรหัสผ่านใหม่และยกเลิกเซสชันเดิม
+		if err := u.checkResetToken(updated, token); err != nil { // ตรวจสอบอีกครั้งภายใต้ล็อก เพื่อให้โทเคนใช้สำเร็จได้เพียงครั้งเดียว
+			return err
+		}
+		updated.ResetTokenHash = "" // ใช้โทเคนแล้ว
+		updated.ResetTokenExpiresAt = time.Time{}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Password reset completed: %s\n", user.Username) // บันทึกการรีเซ็ตรหัสผ่านในล็อก
+	return nil
+}
+
+// checkResetToken ตรวจสอบว่า token ตรงกับโทเคนรีเซ็ตรหัสผ่านของ user และยังไม่หมดอายุ
+func (u *UserUsecase) checkResetToken(user *domain.User, token string) error {
+	if user.ResetTokenHash == "" {
+		return u.Constants.ErrInvalidResetToken
+	}
+	if subtle.ConstantTimeCompare([]byte(user.ResetTokenHash), []byte(hashSessionToken(token))) != 1 {
+		return u.Constants.ErrInvalidResetToken
+	}
+	if time.Now().After(user.ResetTokenExpiresAt) { // โทเคนหมดอายุแล้ว
+		return u.Constants.ErrInvalidResetToken
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// recordingNotifier เก็บข้อความที่ส่งไว้ เพื่อให้การทดสอบอ่านผู้รับและโทเคนได้
+type recordingNotifier struct {
+	mu         sync.Mutex
+	recipients []string
+	bodies     []string
+}
+
+// Send บันทึกผู้รับและเนื้อหาของข้อความ
+func (n *recordingNotifier) Send(recipient, subject, body string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.recipients = append(n.recipients, recipient)
+	n.bodies = append(n.bodies, body)
+	return nil
+}
+
+// lastToken คืนค่าโทเคนในบรรทัดที่สามของข้อความล่าสุด ตามรูปแบบข้อความของ RequestPasswordReset
+func (n *recordingNotifier) lastToken(t *testing.T) string {
+	t.Helper()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.bodies) == 0 {
+		t.Fatal("no message sent")
+	}
+	lines := strings.Split(n.bodies[len(n.bodies)-1], "\n")
+	if len(lines) < 3 {
+		t.Fatalf("unexpected message body %q", n.bodies[len(n.bodies)-1])
+	}
+	return lines[2]
+}
+
+func TestCompleteResetTokenUsableOnce(t *testing.T) {
+	u := newTestUsecase(t)
+	notifier := &recordingNotifier{}
+	u.Notifier = notifier
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	if err := u.RequestPasswordReset("alice"); err != nil {
+		t.Fatalf("RequestPasswordReset error = %v", err)
+	}
+	token := notifier.lastToken(t)
+
+	var successes atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ { // ใช้โทเคนเดียวกันพร้อมกันหลายครั้ง ต้องสำเร็จเพียงครั้งเดียว
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := u.CompleteReset(token, "N3w!Passw0rd#yz"); err == nil {
+				successes.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := successes.Load(); got != 1 {
+		t.Errorf("successful resets with the same token = %d, want 1", got)
+	}
+	if user := mustGetUser(t, u, alice.ID); user.ResetTokenHash != "" {
+		t.Error("reset token still stored after use")
+	}
+}
+
+func TestRequestPasswordResetUsesVerifiedEmailOnly(t *testing.T) {
+	u := newTestUsecase(t)
+	notifier := &recordingNotifier{}
+	u.Notifier = notifier
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	if err := u.SetEmail(alice, alice.ID, "alice@example.com"); err != nil {
+		t.Fatalf("SetEmail error = %v", err)
+	}
+
+	if err := u.RequestPasswordReset("alice"); err != nil {
+		t.Fatalf("RequestPasswordReset error = %v", err)
+	}
+	if got := notifier.recipients[len(notifier.recipients)-1]; got != "alice" {
+		t.Errorf("recipient for unverified email = %q, want username", got)
+	}
+
+	if err := u.RequestEmailVerification(alice, alice.ID); err != nil {
+		t.Fatalf("RequestEmailVerification error = %v", err)
+	}
+	if err := u.VerifyEmail(notifier.lastToken(t)); err != nil {
+		t.Fatalf("VerifyEmail error = %v", err)
+	}
+	if err := u.RequestPasswordReset("alice"); err != nil {
+		t.Fatalf("RequestPasswordReset error = %v", err)
+	}
+	if got := notifier.recipients[len(notifier.recipients)-1]; got != "alice@example.com" {
+		t.Errorf("recipient for verified email = %q, want alice@example.com", got)
+	}
+}
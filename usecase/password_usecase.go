@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+// temporaryPasswordLength ความยาวของรหัสผ่านชั่วคราวเป็น bytes ก่อนเข้ารหัส base64
+const temporaryPasswordLength = 12
+
+// PasswordChangeRequiredError คืนค่าจาก Login เมื่อยืนยันตัวตนสำเร็จแต่ผู้ใช้ต้องเปลี่ยนรหัสผ่านก่อนใช้งาน
+// เช่น หลังจากผู้ดูแลรีเซ็ตรหัสผ่าน ผู้เรียกต้องนำ Challenge ไปเรียก CompletePasswordChange พร้อมรหัสผ่านใหม่
+type PasswordChangeRequiredError struct {
+	Challenge string // โทเคนที่ใช้เปลี่ยนรหัสผ่าน มีอายุตาม Config.SecondFactorTTL
+	reason    error  // Constants.ErrMustChangePassword
+}
+
+// Error คืนค่าข้อความของข้อผิดพลาด
+func (e *PasswordChangeRequiredError) Error() string {
+	return e.reason.Error()
+}
+
+// Unwrap ให้ errors.Is ตรวจสอบกับ Constants.ErrMustChangePassword ได้
+func (e *PasswordChangeRequiredError) Unwrap() error {
+	return e.reason
+}
+
+// CompletePasswordChange ตั้งรหัสผ่านใหม่ด้วย challenge จาก Login และคืนค่าผู้ใช้ที่เข้าสู่ระบบสำเร็จ
+// challenge ใช้ได้จนกว่ารหัสผ่านจะถูกเปลี่ยน หรือหมดอายุ
+func (u *UserUsecase) CompletePasswordChange(challenge, newPassword string) (*domain.User, error) {
+	user, err := u.userFromChallenge(challengePasswordChange, challenge) // ตรวจสอบ challenge และดึงข้อมูลผู้ใช้
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkPasswordPolicy(user.Username, newPassword); err != nil { // ตรวจสอบรหัสผ่านใหม่ตามนโยบาย
+		return nil, err
+	}
+
+	updated, err := u.setPassword(user.ID, newPassword, false, func(current *domain.User) error {
+		if !current.MustChangePassword { // เปลี่ยนรหัสผ่านไปแล้ว challenge นี้จึงใช้ไม่ได้อีก
+			return u.Constants.ErrInvalidChallenge
+		}
+		return u.checkAccountStatus(current)
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.Logger.Printf("Password changed: %s\n", user.Username) // บันทึกการเปลี่ยนรหัสผ่านในล็อก
+	return u.completeLogin(updated)
+}
+
+// passwordChangeRequired สร้าง challenge ที่ลงลายมือชื่อแล้วสำหรับการเปลี่ยนรหัสผ่านที่ถูกบังคับ
+func (u *UserUsecase) passwordChangeRequired(user *domain.User) error {
+	challenge, err := u.newChallenge(challengePasswordChange, user)
+	if err != nil {
+		return err
+	}
+	u.Logger.Printf("Password change required: %s\n", user.Username) // บันทึกว่าต้องเปลี่ยนรหัสผ่านในล็อก
+	return &PasswordChangeRequiredError{
+		Challenge: challenge,
+		reason:    u.Constants.ErrMustChangePassword,
+	}
+}
+
+// ChangePassword เปลี่ยนรหัสผ่านของผู้ใช้ userID โดยต้องยืนยันรหัสผ่านเดิมก่อน ผู้ดำเนินการต้องมีสิทธิ์ PermUserCredentials
+// การยืนยันรหัสผ่านเดิมไม่สำเร็จถูกนับรวมกับการเข้าสู่ระบบไม่สำเร็จ เพื่อไม่ให้ใช้เป็นช่องทางเดารหัสผ่านได้ไม่จำกัด
+func (u *UserUsecase) ChangePassword(actor *domain.User, userID int64, oldPassword, newPassword string) error {
+	user, err := u.UserRepo.GetByID(userID) // ดึงข้อมูลผู้ใช้ตาม ID
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserCredentials, domain.UserResource(user)); err != nil {
+		return err
+	}
+	if err := u.checkAccountStatus(user); err != nil { // บัญชีที่ไม่ได้อยู่ในสถานะใช้งานเปลี่ยนรหัสผ่านไม่ได้
+		return err
+	}
+
+	user, err = u.beginLoginAttempt(user, time.Now()) // ตรวจสอบการล็อกบัญชีและนับการพยายามครั้งนี้ไว้ก่อน
+	if err != nil {
+		return err
+	}
+	if !ValidatePassword(oldPassword, user.Password, user.Salt, u.Config) { // ยืนยันรหัสผ่านเดิม
+		return u.Constants.ErrInvalidPassword
+	}
+	user = u.resetFailedLogins(user)
+
+	if err := u.checkPasswordPolicy(user.Username, newPassword); err != nil { // ตรวจสอบรหัสผ่านใหม่ตามนโยบาย
+		return err
+	}
+
+	if _, err := u.setPassword(user.ID, newPassword, false, func(current *domain.User) error {
+		if current.Password != user.Password { // รหัสผ่านถูกเปลี่ยนไปแล้วหลังจากยืนยันรหัสผ่านเดิม
+			return u.Constants.ErrInvalidPassword
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Password changed: %s\n", user.Username) // บันทึกการเปลี่ยนรหัสผ่านในล็อก
+	return nil
+}
+
+// ResetPassword ให้ผู้ที่มีสิทธิ์ PermUserResetPassword รีเซ็ตรหัสผ่านของผู้ใช้เป็นรหัสผ่านชั่วคราวแบบใช้ครั้งเดียว
+// ผู้ใช้จะต้องเปลี่ยนรหัสผ่านในการเข้าสู่ระบบครั้งถัดไป
+func (u *UserUsecase) ResetPassword(actor *domain.User, targetID int64) (string, error) {
+	target, err := u.UserRepo.GetByID(targetID) // ดึงข้อมูลผู้ใช้ที่ต้องการรีเซ็ต
+	if err != nil {
+		return "", u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserResetPassword, domain.UserResource(target)); err != nil {
+		return "", err
+	}
+
+	temporaryPassword, err := generateTemporaryPassword() // สร้างรหัสผ่านชั่วคราวแบบสุ่ม
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := u.setPassword(target.ID, temporaryPassword, true, nil); err != nil {
+		return "", err
+	}
+
+	u.Logger.Printf("Password reset by %s: %s\n", actor.Username, target.Username) // บันทึกการรีเซ็ตรหัสผ่านในล็อก
+	return temporaryPassword, nil
+}
+
+// setPassword แฮชรหัสผ่านใหม่ของผู้ใช้ userID บันทึกผ่าน modifyUser และยกเลิกเซสชันและ refresh token เดิมทั้งหมดของผู้ใช้
+// check (ถ้าไม่เป็น nil) ถูกเรียกกับข้อมูลล่าสุดของผู้ใช้ภายใต้ล็อกก่อนบันทึก หาก check คืนค่าข้อผิดพลาดจะไม่เปลี่ยนรหัสผ่าน
+func (u *UserUsecase) setPassword(userID int64, password string, mustChange bool, check func(user *domain.User) error) (*domain.User, error) {
+	hashedPassword, err := HashPassword(password, u.Config) // แฮชรหัสผ่านใหม่ก่อนถือล็อก เพราะใช้เวลานาน
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := u.modifyUser(userID, func(updated *domain.User) error {
+		if check != nil {
+			if err := check(updated); err != nil {
+				return err
+			}
+		}
+		updated.Password = hashedPassword       // กำหนดแฮชใหม่
+		updated.Salt = nil                      // แฮชรูปแบบ PHC ไม่ใช้ salt แยก
+		updated.MustChangePassword = mustChange // กำหนดว่าต้องเปลี่ยนรหัสผ่านในครั้งถัดไปหรือไม่
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.revokeUserSessions(updated) // ยกเลิกเซสชันและ refresh token เดิม เพื่อให้ต้องเข้าสู่ระบบด้วยรหัสผ่านใหม่
+	return updated, nil
+}
+
+// generateTemporaryPassword สร้างรหัสผ่านชั่วคราวแบบสุ่ม
+func generateTemporaryPassword() (string, error) {
+	password := make([]byte, temporaryPasswordLength) // สร้าง slice ของ byte ที่มีความยาวตามที่กำหนด
+	if _, err := rand.Read(password); err != nil {    // อ่านข้อมูลแบบสุ่มลงใน password
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(password), nil
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestChangePasswordRequiresAuthorization(t *testing.T) {
+	u := newTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	bob := mustCreateUser(t, u, "bobby", u.Constants.RoleUser)
+
+	if err := u.ChangePassword(bob, alice.ID, testPassword, "N3w!Passw0rd#yz"); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Fatalf("ChangePassword by other user error = %v, want ErrPermissionDenied", err)
+	}
+	if err := u.ChangePassword(alice, alice.ID, testPassword, "N3w!Passw0rd#yz"); err != nil {
+		t.Fatalf("ChangePassword error = %v", err)
+	}
+	if _, err := u.Login("alice", "N3w!Passw0rd#yz"); err != nil {
+		t.Errorf("Login with new password error = %v", err)
+	}
+}
+
+func TestChangePasswordCountsFailures(t *testing.T) {
+	u := newTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	if err := u.ChangePassword(alice, alice.ID, "wrong-password", "N3w!Passw0rd#yz"); !errors.Is(err, u.Constants.ErrInvalidPassword) {
+		t.Fatalf("ChangePassword error = %v, want ErrInvalidPassword", err)
+	}
+	if got := mustGetUser(t, u, alice.ID).FailedLoginAttempts; got != 1 {
+		t.Errorf("FailedLoginAttempts = %d, want 1", got)
+	}
+	if err := u.ChangePassword(alice, alice.ID, testPassword, "N3w!Passw0rd#yz"); !errors.Is(err, u.Constants.ErrAccountLocked) {
+		t.Errorf("immediate retry error = %v, want ErrAccountLocked", err)
+	}
+}
+
+func TestChangePasswordRejectsDisabledAccount(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	if err := u.DisableUser(admin, alice.ID); err != nil {
+		t.Fatalf("DisableUser error = %v", err)
+	}
+
+	if err := u.ChangePassword(admin, alice.ID, testPassword, "N3w!Passw0rd#yz"); !errors.Is(err, u.Constants.ErrAccountDisabled) {
+		t.Errorf("ChangePassword error = %v, want ErrAccountDisabled", err)
+	}
+}
+
+func TestLoginRequiresPasswordChangeAfterReset(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	temporary, err := u.ResetPassword(admin, alice.ID)
+	if err != nil {
+		t.Fatalf("ResetPassword error = %v", err)
+	}
+
+	_, err = u.Login("alice", temporary)
+	var required *PasswordChangeRequiredError
+	if !errors.As(err, &required) || !errors.Is(err, u.Constants.ErrMustChangePassword) {
+		t.Fatalf("Login error = %v, want PasswordChangeRequiredError", err)
+	}
+	if _, _, err := u.IssueSession(mustGetUser(t, u, alice.ID)); !errors.Is(err, u.Constants.ErrMustChangePassword) {
+		t.Errorf("IssueSession error = %v, want ErrMustChangePassword", err)
+	}
+
+	user, err := u.CompletePasswordChange(required.Challenge, "N3w!Passw0rd#yz")
+	if err != nil {
+		t.Fatalf("CompletePasswordChange error = %v", err)
+	}
+	if user.MustChangePassword {
+		t.Error("MustChangePassword still set after CompletePasswordChange")
+	}
+	if _, err := u.CompletePasswordChange(required.Challenge, "An0ther!Passw0rd"); !errors.Is(err, u.Constants.ErrInvalidChallenge) {
+		t.Errorf("reused challenge error = %v, want ErrInvalidChallenge", err)
+	}
+	if _, err := u.Login("alice", "N3w!Passw0rd#yz"); err != nil {
+		t.Errorf("Login with new password error = %v", err)
+	}
+}
+
+func TestChallengesRequireSecretKey(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	temporary, err := u.ResetPassword(admin, alice.ID)
+	if err != nil {
+		t.Fatalf("ResetPassword error = %v", err)
+	}
+	u.Config.SecretKey = nil // ผู้ใช้ไลบรารีที่ไม่ได้กำหนดกุญแจ
+
+	_, err = u.Login("alice", temporary)
+	var required *PasswordChangeRequiredError
+	if err == nil || errors.As(err, &required) {
+		t.Fatalf("Login error = %v, want error without challenge", err)
+	}
+
+	payload := strconv.FormatInt(alice.ID, 10) + "." + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	mac := hmac.New(sha256.New, nil) // ลายมือชื่อที่ผู้โจมตีสร้างได้เองเมื่อกุญแจว่าง
+	mac.Write([]byte(challengePasswordChange + "." + payload))
+	forged := payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if _, err := u.CompletePasswordChange(forged, "N3w!Passw0rd#yz"); !errors.Is(err, u.Constants.ErrInvalidChallenge) {
+		t.Errorf("CompletePasswordChange(forged) error = %v, want ErrInvalidChallenge", err)
+	}
+}
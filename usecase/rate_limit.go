@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"log"
+)
+
+// ชื่อการดำเนินการที่ถูกจำกัดอัตราการเรียก ใช้เป็นคีย์ของ Config.RateLimits
+const (
+	OperationLogin         = "login"          // การเข้าสู่ระบบ
+	OperationCreateUser    = "create_user"    // การสร้างผู้ใช้ใหม่
+	OperationResetPassword = "reset_password" // การรีเซ็ตรหัสผ่าน
+	OperationSecondFactor  = "second_factor"  // การยืนยันขั้นตอนที่สองด้วยรหัส TOTP หรือรหัสกู้คืน
+	OperationCompleteReset = "complete_reset" // การตั้งรหัสผ่านใหม่ด้วยโทเคนรีเซ็ตรหัสผ่าน
+	OperationVerifyEmail   = "verify_email"   // การยืนยันอีเมลด้วยโทเคน
+)
+
+// RateLimiter จำกัดอัตราการเรียกตามคีย์ สามารถเปลี่ยนเป็นที่เก็บร่วมกันระหว่างหลายโปรเซสได้
+type RateLimiter interface {
+	Take(key string, limit domain.RateLimit) (domain.RateLimitStatus, error) // ใช้โควตาหนึ่งครั้งและคืนค่าสถานะ
+	Peek(key string, limit domain.RateLimit) (domain.RateLimitStatus, error) // ดูสถานะโควตาโดยไม่ใช้โควตา
+}
+
+// RateLimitedUserUsecase ครอบ UserUsecase เพื่อจำกัดอัตราการเรียกการยืนยันตัวตนตามผู้เรียก (IP, API key หรือเซสชัน CLI)
+type RateLimitedUserUsecase struct {
+	Usecase *UserUsecase // UserUsecase ที่ถูกครอบ
+	Limiter RateLimiter  // ตัวจำกัดอัตราการเรียก
+	Logger  *log.Logger  // ใช้สำหรับการเขียนล็อก
+}
+
+// NewRateLimitedUserUsecase สร้าง RateLimitedUserUsecase ใหม่
+func NewRateLimitedUserUsecase(usecase *UserUsecase, limiter RateLimiter, logger *log.Logger) *RateLimitedUserUsecase {
+	return &RateLimitedUserUsecase{
+		Usecase: usecase,
+		Limiter: limiter,
+		Logger:  logger,
+	}
+}
+
+// Login เข้าสู่ระบบหากผู้เรียกยังมีโควตาเหลือ
+func (r *RateLimitedUserUsecase) Login(clientID, username, password string) (*domain.User, domain.RateLimitStatus, error) {
+	status, err := r.take(OperationLogin, clientID)
+	if err != nil {
+		return nil, status, err
+	}
+	user, err := r.Usecase.Login(username, password)
+	return user, status, err
+}
+
+// CreateUser สร้างผู้ใช้ใหม่หากผู้เรียกยังมีโควตาเหลือ
+func (r *RateLimitedUserUsecase) CreateUser(clientID string, user *domain.User, role string) (domain.RateLimitStatus, error) {
+	status, err := r.take(OperationCreateUser, clientID)
+	if err != nil {
+		return status, err
+	}
+	return status, r.Usecase.CreateUser(user, role)
+}
+
+// ResetPassword รีเซ็ตรหัสผ่านหากผู้เรียกยังมีโควตาเหลือ
+func (r *RateLimitedUserUsecase) ResetPassword(clientID string, actor *domain.User, targetID int64) (string, domain.RateLimitStatus, error) {
+	status, err := r.take(OperationResetPassword, clientID)
+	if err != nil {
+		return "", status, err
+	}
+	password, err := r.Usecase.ResetPassword(actor, targetID)
+	return password, status, err
+}
+
+// RequestPasswordReset ขอโทเคนรีเซ็ตรหัสผ่านหากผู้เรียกยังมีโควตาเหลือ
+func (r *RateLimitedUserUsecase) RequestPasswordReset(clientID, username string) (domain.RateLimitStatus, error) {
+	status, err := r.take(OperationResetPassword, clientID)
+	if err != nil {
+		return status, err
+	}
+	return status, r.Usecase.RequestPasswordReset(username)
+}
+
+// CompleteSecondFactor ยืนยันรหัส TOTP ของขั้นตอนที่สองหากผู้เรียกยังมีโควตาเหลือ
+func (r *RateLimitedUserUsecase) CompleteSecondFactor(clientID, challenge, code string) (*domain.User, domain.RateLimitStatus, error) {
+	status, err := r.take(OperationSecondFactor, clientID)
+	if err != nil {
+		return nil, status, err
+	}
+	user, err := r.Usecase.CompleteSecondFactor(challenge, code)
+	return user, status, err
+}
+
+// CompleteSecondFactorWithRecoveryCode ยืนยันขั้นตอนที่สองด้วยรหัสกู้คืนหากผู้เรียกยังมีโควตาเหลือ
+// ใช้โควตาเดียวกับ CompleteSecondFactor เพื่อไม่ให้สลับวิธีเพื่อเดารหัสได้มากขึ้น
+func (r *RateLimitedUserUsecase) CompleteSecondFactorWithRecoveryCode(clientID, challenge, code string) (*domain.User, domain.RateLimitStatus, error) {
+	status, err := r.take(OperationSecondFactor, clientID)
+	if err != nil {
+		return nil, status, err
+	}
+	user, err := r.Usecase.CompleteSecondFactorWithRecoveryCode(challenge, code)
+	return user, status, err
+}
+
+// CompleteReset ตั้งรหัสผ่านใหม่ด้วยโทเคนรีเซ็ตรหัสผ่านหากผู้เรียกยังมีโควตาเหลือ
+func (r *RateLimitedUserUsecase) CompleteReset(clientID, token, newPassword string) (domain.RateLimitStatus, error) {
+	status, err := r.take(OperationCompleteReset, clientID)
+	if err != nil {
+		return status, err
+	}
+	return status, r.Usecase.CompleteReset(token, newPassword)
+}
+
+// VerifyEmail ยืนยันอีเมลด้วยโทเคนหากผู้เรียกยังมีโควตาเหลือ
+func (r *RateLimitedUserUsecase) VerifyEmail(clientID, token string) (domain.RateLimitStatus, error) {
+	status, err := r.take(OperationVerifyEmail, clientID)
+	if err != nil {
+		return status, err
+	}
+	return status, r.Usecase.VerifyEmail(token)
+}
+
+// Quota คืนค่าโควตาที่เหลือของผู้เรียกสำหรับการดำเนินการที่ระบุ โดยไม่ใช้โควตา
+func (r *RateLimitedUserUsecase) Quota(operation, clientID string) (domain.RateLimitStatus, error) {
+	limit, exists := r.Usecase.Config.RateLimits[operation]
+	if !exists { // การดำเนินการที่ไม่ได้กำหนดขีดจำกัด
+		return domain.RateLimitStatus{Allowed: true}, nil
+	}
+	return r.Limiter.Peek(rateLimitKey(operation, clientID), limit)
+}
+
+// take ใช้โควตาของผู้เรียกหนึ่งครั้ง และคืนค่า ErrRateLimited หากโควตาหมด
+func (r *RateLimitedUserUsecase) take(operation, clientID string) (domain.RateLimitStatus, error) {
+	limit, exists := r.Usecase.Config.RateLimits[operation]
+	if !exists { // การดำเนินการที่ไม่ได้กำหนดขีดจำกัด
+		return domain.RateLimitStatus{Allowed: true}, nil
+	}
+
+	status, err := r.Limiter.Take(rateLimitKey(operation, clientID), limit)
+	if err != nil {
+		return status, err
+	}
+	if !status.Allowed {
+		r.Logger.Printf("Rate limit exceeded: %s by %s, retry after %s\n", operation, clientID, status.RetryAfter) // บันทึกการถูกจำกัดในล็อก
+		return status, r.Usecase.Constants.ErrRateLimited
+	}
+	return status, nil
+}
+
+// rateLimitKey สร้างคีย์ของ bucket จากการดำเนินการและผู้เรียก
+func rateLimitKey(operation, clientID string) string {
+	return operation + ":" + clientID
+}
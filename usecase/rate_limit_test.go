@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"Basic_login/repository"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedTokenEndpoints(t *testing.T) {
+	u := newTestUsecase(t)
+	once := domain.RateLimit{Capacity: 1, RefillEvery: time.Hour}
+	u.Config.RateLimits = map[string]domain.RateLimit{
+		OperationSecondFactor:  once,
+		OperationCompleteReset: once,
+		OperationVerifyEmail:   once,
+	}
+	r := NewRateLimitedUserUsecase(u, repository.NewInMemoryRateLimiter(), log.New(io.Discard, "", 0))
+
+	calls := map[string]func(clientID string) error{
+		"CompleteSecondFactor": func(clientID string) error {
+			_, _, err := r.CompleteSecondFactor(clientID, "bad", "000000")
+			return err
+		},
+		"CompleteReset": func(clientID string) error {
+			_, err := r.CompleteReset(clientID, "bad", testPassword)
+			return err
+		},
+		"VerifyEmail": func(clientID string) error {
+			_, err := r.VerifyEmail(clientID, "bad")
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call("client-a"); err == nil || errors.Is(err, u.Constants.ErrRateLimited) {
+			t.Errorf("%s first call error = %v, want a token error", name, err)
+		}
+		if err := call("client-a"); !errors.Is(err, u.Constants.ErrRateLimited) {
+			t.Errorf("%s second call error = %v, want ErrRateLimited", name, err)
+		}
+		if err := call("client-b"); errors.Is(err, u.Constants.ErrRateLimited) {
+			t.Errorf("%s from another client error = %v, want its own quota", name, err)
+		}
+	}
+
+	// รหัสกู้คืนใช้โควตาเดียวกับรหัส TOTP
+	if _, _, err := r.CompleteSecondFactorWithRecoveryCode("client-a", "bad", "recovery"); !errors.Is(err, u.Constants.ErrRateLimited) {
+		t.Errorf("CompleteSecondFactorWithRecoveryCode error = %v, want ErrRateLimited", err)
+	}
+}
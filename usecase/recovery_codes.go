@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+)
+
+// recoveryCodeBytes จำนวน bytes แบบสุ่มของรหัสกู้คืนแต่ละรหัส (เข้ารหัสเป็น base32 ได้ 16 ตัวอักษร)
+const recoveryCodeBytes = 10
+
+// recoveryCodeTagBytes จำนวน bytes ของ HMAC ที่ใช้เป็นดัชนีของรหัสกู้คืนแต่ละรหัส
+const recoveryCodeTagBytes = 8
+
+// GenerateRecoveryCodes สร้างรหัสกู้คืนชุดใหม่แทนชุดเดิมทั้งหมด และคืนค่ารหัสที่เป็นข้อความธรรมดาให้ผู้ใช้เก็บไว้
+// ระบบเก็บเฉพาะแฮชของรหัสในรูปแบบ <tag>:<แฮช> โดย tag เป็น HMAC แบบสั้นที่ใช้ค้นหารหัสที่ตรงกัน
+// เพื่อให้การใช้รหัสกู้คืนแฮชด้วย Argon2 เพียงครั้งเดียว ระบบแสดงรหัสได้เพียงครั้งเดียว
+func (u *UserUsecase) GenerateRecoveryCodes(actor *domain.User, userID int64) ([]string, error) {
+	user, err := u.UserRepo.GetByID(userID) // ดึงข้อมูลผู้ใช้ตาม ID
+	if err != nil {
+		return nil, u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserCredentials, domain.UserResource(user)); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, u.Config.RecoveryCodeCount)  // รหัสที่คืนให้ผู้ใช้
+	hashes := make([]string, 0, u.Config.RecoveryCodeCount) // แฮชที่เก็บไว้ในระบบ
+	for i := 0; i < u.Config.RecoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		normalized := normalizeRecoveryCode(code)
+		tag, err := u.recoveryCodeTag(normalized)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := HashPassword(normalized, u.Config) // แฮชด้วยวิธีเดียวกับรหัสผ่าน
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, tag+":"+hash)
+	}
+
+	if _, err := u.modifyUser(user.ID, func(updated *domain.User) error {
+		updated.RecoveryCodes = hashes // แทนที่รหัสชุดเดิมทั้งหมด
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	u.Logger.Printf("Recovery codes generated: %s\n", user.Username) // บันทึกการสร้างรหัสกู้คืนในล็อก
+	return codes, nil
+}
+
+// RemainingRecoveryCodes คืนค่าจำนวนรหัสกู้คืนที่ยังไม่ถูกใช้ ผู้ดำเนินการต้องมีสิทธิ์ PermUserCredentials
+func (u *UserUsecase) RemainingRecoveryCodes(actor *domain.User, userID int64) (int, error) {
+	user, err := u.UserRepo.GetByID(userID)
+	if err != nil {
+		return 0, u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserCredentials, domain.UserResource(user)); err != nil {
+		return 0, err
+	}
+	return len(user.RecoveryCodes), nil
+}
+
+// CompleteSecondFactorWithRecoveryCode ยืนยันขั้นตอนที่สองของการเข้าสู่ระบบด้วยรหัสกู้คืนแทนรหัส TOTP
+// รหัสที่ใช้แล้วจะถูกลบออกและใช้ซ้ำไม่ได้
+func (u *UserUsecase) CompleteSecondFactorWithRecoveryCode(challenge, code string) (*domain.User, error) {
+	user, err := u.userFromChallenge(challengeSecondFactor, challenge) // ตรวจสอบ challenge และดึงข้อมูลผู้ใช้
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkAccountStatus(user); err != nil { // บัญชีอาจถูกระงับหลังจากออก challenge
+		return nil, u.loginFailure(user.Username, err)
+	}
+
+	if _, err := u.beginLoginAttempt(user, time.Now()); err != nil { // ใช้การล็อกบัญชีเดียวกับการตรวจสอบรหัสผ่าน
+		return nil, u.loginFailure(user.Username, err)
+	}
+
+	updated, err := u.consumeRecoveryCode(user, code)
+	if err != nil { // รหัสผิดถูกนับเป็นการเข้าสู่ระบบไม่สำเร็จไว้แล้ว
+		return nil, u.loginFailure(user.Username, err)
+	}
+	updated = u.resetFailedLogins(updated)
+
+	u.Logger.Printf("Login with recovery code: %s, %d codes remaining\n", updated.Username, len(updated.RecoveryCodes)) // บันทึกการใช้รหัสกู้คืนในล็อก
+	return u.completeLogin(updated)
+}
+
+// consumeRecoveryCode ค้นหารหัสกู้คืนที่ตรงกันด้วย tag และตรวจสอบด้วย Argon2 เพียงครั้งเดียวโดยไม่ถือล็อก
+// แล้วลบรหัสออกจากรายการของผู้ใช้ภายใต้ล็อก เพื่อไม่ให้รหัสเดียวกันถูกใช้สำเร็จสองครั้งจากการเรียกพร้อมกัน
+func (u *UserUsecase) consumeRecoveryCode(user *domain.User, code string) (*domain.User, error) {
+	normalized := normalizeRecoveryCode(code)
+	tag, err := u.recoveryCodeTag(normalized)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := u.matchRecoveryCode(user.RecoveryCodes, tag, normalized)
+	if !ok {
+		return nil, u.Constants.ErrInvalidRecoveryCode
+	}
+
+	return u.modifyUser(user.ID, func(updated *domain.User) error {
+		i := slices.Index(updated.RecoveryCodes, entry)
+		if i < 0 { // รหัสถูกใช้ไปแล้วโดยการเรียกพร้อมกัน หรือถูกสร้างชุดใหม่แทน
+			return u.Constants.ErrInvalidRecoveryCode
+		}
+		updated.RecoveryCodes = slices.Delete(slices.Clone(updated.RecoveryCodes), i, i+1) // สร้างรายการใหม่โดยไม่มีรหัสที่ใช้แล้ว
+		return nil
+	})
+}
+
+// matchRecoveryCode คืนค่ารายการใน entries ที่ตรงกับรหัส normalized โดยตรวจสอบด้วย Argon2 เฉพาะรายการที่มี tag ตรงกัน
+// รายการรูปแบบเดิมที่ไม่มี tag จะถูกตรวจสอบทีละรายการ
+func (u *UserUsecase) matchRecoveryCode(entries []string, tag, normalized string) (string, bool) {
+	for _, entry := range entries {
+		entryTag, hash, tagged := strings.Cut(entry, ":")
+		if !tagged { // รูปแบบเดิมที่เก็บเฉพาะแฮช
+			hash = entry
+		} else if entryTag != tag {
+			continue
+		}
+		if ValidatePassword(normalized, hash, nil, u.Config) {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// recoveryCodeTag คืนค่า HMAC แบบสั้นของรหัสกู้คืนด้วย Config.SecretKey สำหรับใช้เป็นดัชนี
+func (u *UserUsecase) recoveryCodeTag(normalized string) (string, error) {
+	mac, err := signMessage(u.Config.SecretKey, []byte("recovery."+normalized))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(mac[:recoveryCodeTagBytes]), nil
+}
+
+// generateRecoveryCode สร้างรหัสกู้คืนแบบสุ่มในรูปแบบ xxxx-xxxx-xxxx-xxxx
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, recoveryCodeBytes)    // สร้าง slice ของ byte ที่มีความยาวตามที่กำหนด
+	if _, err := rand.Read(raw); err != nil { // อ่านข้อมูลแบบสุ่มลงใน raw
+		return "", err
+	}
+
+	encoded := strings.ToLower(totpEncoding.EncodeToString(raw)) // เข้ารหัสเป็น base32 ตัวพิมพ์เล็ก
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 { // แบ่งเป็นกลุ่มละ 4 ตัวอักษรเพื่อให้อ่านง่าย
+		groups = append(groups, encoded[i:i+4])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// normalizeRecoveryCode ตัดขีดและช่องว่าง และแปลงเป็นตัวพิมพ์เล็ก เพื่อให้ผู้ใช้พิมพ์รหัสได้หลายรูปแบบ
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecoveryCodeUsableOnce(t *testing.T) {
+	u := newTOTPTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	enrollTOTP(t, u, alice.ID)
+	codes, err := u.GenerateRecoveryCodes(alice, alice.ID)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes error = %v", err)
+	}
+
+	_, err = u.Login("alice", testPassword)
+	var required *SecondFactorRequiredError
+	if !errors.As(err, &required) {
+		t.Fatalf("Login error = %v, want SecondFactorRequiredError", err)
+	}
+
+	var successes atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ { // ส่งรหัสกู้คืนเดียวกันพร้อมกันหลายครั้ง ต้องสำเร็จเพียงครั้งเดียว
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := u.CompleteSecondFactorWithRecoveryCode(required.Challenge, codes[0]); err == nil {
+				successes.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := successes.Load(); got != 1 {
+		t.Errorf("successful logins with the same recovery code = %d, want 1", got)
+	}
+	if remaining, _ := u.RemainingRecoveryCodes(alice, alice.ID); remaining != len(codes)-1 {
+		t.Errorf("RemainingRecoveryCodes = %d, want %d", remaining, len(codes)-1)
+	}
+}
+
+func TestDisableTOTPClearsRecoveryCodes(t *testing.T) {
+	u := newTOTPTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	secret := enrollTOTP(t, u, alice.ID)
+	if _, err := u.GenerateRecoveryCodes(alice, alice.ID); err != nil {
+		t.Fatalf("GenerateRecoveryCodes error = %v", err)
+	}
+
+	code := generateTOTP(secret, currentTOTPCounter(u), u.Config.TOTPDigits)
+	if err := u.DisableTOTP(alice, alice.ID, code); err != nil {
+		t.Fatalf("DisableTOTP error = %v", err)
+	}
+	if remaining, _ := u.RemainingRecoveryCodes(alice, alice.ID); remaining != 0 {
+		t.Errorf("RemainingRecoveryCodes after DisableTOTP = %d, want 0", remaining)
+	}
+}
+
+func TestRemainingRecoveryCodesRequiresAuthorization(t *testing.T) {
+	u := newTOTPTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	bob := mustCreateUser(t, u, "bobby", u.Constants.RoleUser)
+
+	if _, err := u.RemainingRecoveryCodes(bob, alice.ID); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("RemainingRecoveryCodes by other user error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestConsumeLegacyRecoveryCode(t *testing.T) {
+	u := newTOTPTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	hash, err := HashPassword("abcdabcdabcdabcd", u.Config)
+	if err != nil {
+		t.Fatalf("HashPassword error = %v", err)
+	}
+	if _, err := u.modifyUser(alice.ID, func(updated *domain.User) error {
+		updated.RecoveryCodes = []string{hash} // รูปแบบเดิมที่ไม่มี tag
+		return nil
+	}); err != nil {
+		t.Fatalf("modifyUser error = %v", err)
+	}
+
+	if _, err := u.consumeRecoveryCode(mustGetUser(t, u, alice.ID), "wrong-code"); !errors.Is(err, u.Constants.ErrInvalidRecoveryCode) {
+		t.Errorf("consumeRecoveryCode(wrong) error = %v, want ErrInvalidRecoveryCode", err)
+	}
+	updated, err := u.consumeRecoveryCode(mustGetUser(t, u, alice.ID), "ABCD-abcd-abcd-abcd")
+	if err != nil {
+		t.Fatalf("consumeRecoveryCode error = %v", err)
+	}
+	if len(updated.RecoveryCodes) != 0 {
+		t.Errorf("RecoveryCodes = %v, want empty", updated.RecoveryCodes)
+	}
+}
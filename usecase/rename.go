@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"time"
+)
+
+// RenameUser เปลี่ยนชื่อผู้ใช้ โดยตรวจสอบชื่อใหม่ ปรับดัชนีใน UserRepo พร้อมกัน และบันทึกชื่อเดิมไว้ในประวัติ
+// ชื่อเดิมจะถูกสงวนไว้ตาม Config.UsernameReservation เพื่อไม่ให้ผู้อื่นนำไปใช้สวมรอย
+func (u *UserUsecase) RenameUser(actor *domain.User, userID int64, newUsername string) error {
+	newUsername, err := u.validateUsername(newUsername) // ตรวจสอบชื่อใหม่และแปลงเป็นรูปแบบมาตรฐาน
+	if err != nil {
+		return err
+	}
+
+	user, err := u.UserRepo.GetByID(userID) // ดึงข้อมูลผู้ใช้ตาม ID
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserUpdate, domain.UserResource(user)); err != nil {
+		return err
+	}
+	if user.Username == newUsername { // ชื่อเดิม ไม่ต้องเปลี่ยนแปลง
+		return nil
+	}
+
+	if _, err := u.UserRepo.GetByUsername(newUsername); err == nil { // ชื่อใหม่ถูกใช้โดยผู้ใช้อื่นแล้ว
+		return u.Constants.ErrUsernameAlreadyExists
+	}
+	if err := u.checkReservedUsername(newUsername); err != nil { // ชื่อที่สงวนไว้สำหรับระบบ
+		return err
+	}
+	if err := u.checkUsernameReservation(newUsername, userID); err != nil {
+		return err
+	}
+
+	unlock := u.userLocks.lock(userID) // ถือล็อกของผู้ใช้ เพื่อไม่ให้ modifyUser ที่อ่านข้อมูลก่อนเปลี่ยนชื่อเขียนชื่อเดิมทับกลับ
+	defer unlock()
+	if _, err := u.UserRepo.Rename(userID, newUsername); err != nil {
+		if _, lookupErr := u.UserRepo.GetByUsername(newUsername); lookupErr == nil {
+			return u.Constants.ErrUsernameAlreadyExists // มีผู้ใช้อื่นได้ชื่อนี้ไปก่อนระหว่างการตรวจสอบ
+		}
+		return err
+	}
+
+	u.Logger.Printf("User renamed: %s to %s\n", user.Username, newUsername) // บันทึกการเปลี่ยนชื่อในล็อก
+	return nil
+}
+
+// checkUsernameReservation ตรวจสอบว่าชื่อผู้ใช้ถูกสงวนไว้จากการเปลี่ยนชื่อของผู้ใช้อื่นหรือไม่
+// ผู้ใช้ที่เป็นเจ้าของชื่อเดิม (ownerID) สามารถเปลี่ยนกลับมาใช้ชื่อเดิมได้
+func (u *UserUsecase) checkUsernameReservation(username string, ownerID int64) error {
+	if u.Config.UsernameReservation <= 0 { // ไม่ได้เปิดใช้การสงวนชื่อ
+		return nil
+	}
+
+	users, err := u.UserRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-u.Config.UsernameReservation) // ชื่อที่เปลี่ยนออกไปหลังเวลานี้ยังถูกสงวนอยู่
+	for _, user := range users {
+		if user.ID == ownerID {
+			continue
+		}
+		for _, change := range user.PreviousUsernames {
+			if change.Username == username && change.ChangedAt.After(cutoff) {
+				return u.Constants.ErrUsernameReserved
+			}
+		}
+	}
+	return nil
+}
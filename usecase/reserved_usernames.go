@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"bufio"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ReservedUsernames เก็บรายชื่อผู้ใช้และรูปแบบ (glob เช่น "admin*") ที่ไม่อนุญาตให้ผู้ใช้ทั่วไปลงทะเบียน
+// ทุกชื่อและรูปแบบจะถูกแปลงด้วย CanonicalUsername ก่อนเก็บ เพื่อให้ตรวจสอบแบบเดียวกับความซ้ำของชื่อผู้ใช้
+type ReservedUsernames struct {
+	mu       sync.RWMutex        // ป้องกันการเข้าถึงพร้อมกันระหว่างการโหลดและการตรวจสอบ
+	names    map[string]struct{} // ชื่อที่สงวนไว้ในรูปแบบมาตรฐาน
+	patterns []string            // รูปแบบ glob ในรูปแบบมาตรฐาน (ใช้กับ path.Match)
+}
+
+// NewDefaultReservedUsernames สร้างรายชื่อที่สงวนไว้พื้นฐาน
+func NewDefaultReservedUsernames() *ReservedUsernames {
+	reserved := &ReservedUsernames{names: make(map[string]struct{})}
+	reserved.Add("admin", "root", "system", "moderator")
+	return reserved
+}
+
+// Add เพิ่มชื่อที่สงวนไว้
+func (r *ReservedUsernames) Add(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names == nil {
+		r.names = make(map[string]struct{}, len(names))
+	}
+	for _, name := range names {
+		r.names[canonicalReserved(name)] = struct{}{}
+	}
+}
+
+// AddPattern เพิ่มรูปแบบ glob ที่สงวนไว้ เช่น "admin*" หรือ "*_bot" และคืนค่าข้อผิดพลาดหากรูปแบบไม่ถูกต้อง
+func (r *ReservedUsernames) AddPattern(pattern string) error {
+	pattern = canonicalReserved(pattern)
+	if _, err := path.Match(pattern, ""); err != nil { // ตรวจสอบรูปแบบก่อนเก็บ
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns = append(r.patterns, pattern)
+	return nil
+}
+
+// Load โหลดชื่อและรูปแบบที่สงวนไว้จากไฟล์ บรรทัดละหนึ่งรายการ (บรรทัดที่ขึ้นต้นด้วย # ถือเป็นคอมเมนต์)
+// บรรทัดที่มี *, ? หรือ [ ถือเป็นรูปแบบ glob
+func (r *ReservedUsernames) Load(filePath string) error {
+	file, err := os.Open(filePath) // เปิดไฟล์รายชื่อที่สงวนไว้
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") { // ข้ามบรรทัดว่างและคอมเมนต์
+			continue
+		}
+		if strings.ContainsAny(line, "*?[") {
+			if err := r.AddPattern(line); err != nil {
+				return err
+			}
+			continue
+		}
+		r.Add(line)
+	}
+	return scanner.Err()
+}
+
+// IsReserved ตรวจสอบว่าชื่อผู้ใช้ (ในรูปแบบมาตรฐาน) ถูกสงวนไว้หรือไม่
+func (r *ReservedUsernames) IsReserved(username string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.names[username]; exists {
+		return true
+	}
+	for _, pattern := range r.patterns {
+		if matched, _ := path.Match(pattern, username); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalReserved แปลงชื่อหรือรูปแบบที่สงวนไว้เป็นรูปแบบมาตรฐาน หาก PRECIS ไม่รับให้ใช้ตัวพิมพ์เล็กแทน
+func canonicalReserved(name string) string {
+	if canonical, err := CanonicalUsername(strings.TrimSpace(name)); err == nil {
+		return canonical
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// CreateReservedUser ให้ผู้ที่มีสิทธิ์ PermUserReservedName สร้างผู้ใช้ที่มีชื่อซึ่งถูกสงวนไว้ได้ เช่น บัญชี "admin" ของระบบ
+func (u *UserUsecase) CreateReservedUser(actor, user *domain.User, role string) error {
+	if err := u.Authorize(actor, domain.PermUserReservedName, domain.Resource{Kind: domain.ResourceUser}); err != nil {
+		return err
+	}
+	if err := u.authorizeCreate(actor, role); err != nil {
+		return err
+	}
+	return u.createUser(user, role, true)
+}
+
+// checkReservedUsername ตรวจสอบว่าชื่อผู้ใช้ (ในรูปแบบมาตรฐาน) ไม่อยู่ในรายชื่อที่สงวนไว้
+func (u *UserUsecase) checkReservedUsername(username string) error {
+	if u.Reserved != nil && u.Reserved.IsReserved(username) {
+		return u.Constants.ErrUsernameNotAllowed
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"slices"
+)
+
+// IsValidRole ตรวจสอบว่ามีบทบาทชื่อ name อยู่ใน RoleRepo หรือไม่
+func (u *UserUsecase) IsValidRole(name string) bool {
+	_, err := u.RoleRepo.GetByName(name)
+	return err == nil
+}
+
+// ListRoles คืนค่าบทบาททั้งหมดในระบบ
+func (u *UserUsecase) ListRoles() ([]*domain.Role, error) {
+	return u.RoleRepo.GetAll()
+}
+
+// CreateRole สร้างบทบาทใหม่ เช่น "moderator" หรือ "auditor" ซึ่งสืบทอดสิทธิ์จากบทบาทอื่นได้ผ่าน Role.Inherits
+func (u *UserUsecase) CreateRole(actor *domain.User, role *domain.Role) error {
+	if err := u.Authorize(actor, domain.PermRoleManage, domain.Resource{Kind: domain.ResourceRole}); err != nil {
+		return err
+	}
+	if _, err := u.RoleRepo.GetByName(role.Name); err == nil { // มีบทบาทนี้อยู่แล้ว
+		return u.Constants.ErrRoleExists
+	}
+	if err := u.validateRole(role); err != nil {
+		return err
+	}
+	if err := u.RoleRepo.Create(role); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Role created by %s: %s\n", actor.Username, role.Name) // บันทึกการสร้างบทบาทในล็อก
+	return nil
+}
+
+// UpdateRole ปรับปรุงชุดสิทธิ์และการสืบทอดของบทบาทที่มีอยู่
+func (u *UserUsecase) UpdateRole(actor *domain.User, role *domain.Role) error {
+	if err := u.Authorize(actor, domain.PermRoleManage, domain.Resource{Kind: domain.ResourceRole}); err != nil {
+		return err
+	}
+	if !u.IsValidRole(role.Name) {
+		return u.Constants.ErrRoleNotFound
+	}
+	if err := u.validateRole(role); err != nil {
+		return err
+	}
+	if err := u.RoleRepo.Update(role); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Role updated by %s: %s\n", actor.Username, role.Name) // บันทึกการปรับปรุงบทบาทในล็อก
+	return nil
+}
+
+// DeleteRole ลบบทบาท โดยไม่อนุญาตให้ลบบทบาทพื้นฐาน บทบาทที่ผู้ใช้หรือกลุ่มยังถืออยู่ หรือบทบาทที่บทบาทอื่นสืบทอดอยู่
+func (u *UserUsecase) DeleteRole(actor *domain.User, name string) error {
+	if err := u.Authorize(actor, domain.PermRoleManage, domain.Resource{Kind: domain.ResourceRole}); err != nil {
+		return err
+	}
+	if !u.IsValidRole(name) {
+		return u.Constants.ErrRoleNotFound
+	}
+	if name == u.Constants.RoleAdmin || name == u.Constants.RoleUser { // บทบาทพื้นฐานต้องมีอยู่เสมอ
+		return u.Constants.ErrRoleInUse
+	}
+
+	users, err := u.UserRepo.GetAll() // ตรวจสอบว่ายังมีผู้ใช้ถือบทบาทนี้หรือไม่
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Role == name {
+			return u.Constants.ErrRoleInUse
+		}
+	}
+
+	if u.GroupRepo != nil { // ตรวจสอบว่ายังมีกลุ่มถือบทบาทนี้หรือไม่
+		groups, err := u.GroupRepo.GetAll()
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			if slices.Contains(group.Roles, name) {
+				return u.Constants.ErrRoleInUse
+			}
+		}
+	}
+
+	roles, err := u.RoleRepo.GetAll() // ตรวจสอบว่ามีบทบาทอื่นสืบทอดบทบาทนี้หรือไม่
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if slices.Contains(role.Inherits, name) {
+			return u.Constants.ErrRoleInUse
+		}
+	}
+
+	if err := u.RoleRepo.Delete(name); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Role deleted by %s: %s\n", actor.Username, name) // บันทึกการลบบทบาทในล็อก
+	return nil
+}
+
+// AssignRole กำหนดบทบาทให้ผู้ใช้ ผู้ดำเนินการต้องมีสิทธิ์ PermRoleAssign และต้องมีสิทธิ์ทุกข้อของบทบาทที่กำหนดอยู่แล้ว
+// เพื่อไม่ให้ผู้ที่มีเพียง PermRoleAssign กำหนดบทบาทที่มีสิทธิ์มากกว่าตนเอง เช่น admin ให้ตนเองได้
+func (u *UserUsecase) AssignRole(actor *domain.User, userID int64, role string) error {
+	target, err := u.UserRepo.GetByID(userID) // ดึงข้อมูลผู้ใช้ที่ต้องการกำหนดบทบาท
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.authorizeGrant(actor, domain.UserResource(target), []string{role}, nil); err != nil {
+		return err
+	}
+	if !u.IsValidRole(role) {
+		return u.Constants.ErrRoleNotFound
+	}
+
+	if _, err := u.modifyUser(target.ID, func(updated *domain.User) error {
+		updated.Role = role
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	u.Logger.Printf("Role assigned by %s: %s is now %s\n", actor.Username, target.Username, role) // บันทึกการกำหนดบทบาทในล็อก
+	return nil
+}
+
+// validateRole ตรวจสอบว่าบทบาทมีชื่อ สิทธิ์ทั้งหมดเป็นสิทธิ์ที่รู้จัก บทบาทที่สืบทอดมีอยู่จริง และการสืบทอดไม่วนกลับมาที่ตนเอง
+func (u *UserUsecase) validateRole(role *domain.Role) error {
+	if role.Name == "" {
+		return u.Constants.ErrInvalidRole
+	}
+	for _, permission := range append(slices.Clone(role.Permissions), role.OwnPermissions...) {
+		if !slices.Contains(domain.AllPermissions, permission) {
+			return u.Constants.ErrInvalidRole
+		}
+	}
+	for _, parent := range role.Inherits {
+		if !u.IsValidRole(parent) {
+			return u.Constants.ErrRoleNotFound
+		}
+		if parent == role.Name || u.inheritsFrom(parent, role.Name, make(map[string]bool)) {
+			return u.Constants.ErrInvalidRole // การสืบทอดวนซ้ำ
+		}
+	}
+	return nil
+}
+
+// inheritsFrom ตรวจสอบว่าบทบาท name สืบทอด (ทางตรงหรือทางอ้อม) จากบทบาท ancestor หรือไม่
+func (u *UserUsecase) inheritsFrom(name, ancestor string, visited map[string]bool) bool {
+	if visited[name] {
+		return false
+	}
+	visited[name] = true
+
+	role, err := u.RoleRepo.GetByName(name)
+	if err != nil {
+		return false
+	}
+	for _, parent := range role.Inherits {
+		if parent == ancestor || u.inheritsFrom(parent, ancestor, visited) {
+			return true
+		}
+	}
+	return false
+}
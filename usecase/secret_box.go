@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+// secretKeyLength ความยาวของ Config.SecretKey ที่ต้องการ (AES-256)
+const secretKeyLength = 32
+
+// errInvalidCiphertext ข้อผิดพลาดเมื่อข้อมูลที่เข้ารหัสไว้ถูกแก้ไขหรือไม่สมบูรณ์
+var errInvalidCiphertext = errors.New("invalid ciphertext")
+
+// errInvalidSecretKey ข้อผิดพลาดเมื่อ Config.SecretKey ไม่ได้กำหนดหรือมีขนาดไม่ถูกต้อง
+var errInvalidSecretKey = errors.New("secret key must be 32 bytes")
+
+// encryptSecret เข้ารหัสข้อมูลลับด้วย AES-256-GCM โดยใส่ nonce ไว้ด้านหน้าผลลัพธ์
+func encryptSecret(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())      // สร้าง nonce แบบสุ่ม
+	if _, err := rand.Read(nonce); err != nil { // อ่านข้อมูลแบบสุ่มลงใน nonce
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// decryptSecret ถอดรหัสข้อมูลที่เข้ารหัสด้วย encryptSecret
+func decryptSecret(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errInvalidCiphertext
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// newGCM สร้าง AES-GCM จากกุญแจ
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != secretKeyLength {
+		return nil, errInvalidSecretKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// signMessage สร้างลายมือชื่อ HMAC-SHA256 ของข้อความด้วยกุญแจ
+// กุญแจต้องมีขนาด 32 bytes เหมือน encryptSecret เพราะกุญแจว่างหรือสั้นทำให้ผู้อื่นปลอมลายมือชื่อได้
+func signMessage(key, message []byte) ([]byte, error) {
+	if len(key) != secretKeyLength {
+		return nil, errInvalidSecretKey
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// โครงสร้าง interface SessionRepository ใช้สำหรับการดำเนินการกับเซสชันของผู้ใช้
+type SessionRepository interface {
+	Create(session *domain.Session) error                // สร้างเซสชันใหม่
+	GetByID(id string) (*domain.Session, error)          // ดึงเซสชันตามรหัสเซสชัน
+	GetByUserID(userID int64) ([]*domain.Session, error) // ดึงเซสชันทั้งหมดของผู้ใช้
+	Update(session *domain.Session) error                // ปรับปรุงข้อมูลเซสชัน เช่น เวลาที่ใช้งานล่าสุด
+	Delete(id string) error                              // ลบเซสชันตามรหัสเซสชัน
+	DeleteByUserID(userID int64) error                   // ลบเซสชันทั้งหมดของผู้ใช้
+}
+
+// IssueSession สร้างเซสชันใหม่ให้ผู้ใช้ที่เข้าสู่ระบบแล้ว และคืนค่าโทเคนที่ใช้ยืนยันตัวตนในครั้งถัดไป
+// ผู้ใช้ที่ต้องเปลี่ยนรหัสผ่านจะไม่ได้รับเซสชันจนกว่าจะเปลี่ยนรหัสผ่านผ่าน CompletePasswordChange
+func (u *UserUsecase) IssueSession(user *domain.User) (string, *domain.Session, error) {
+	if user.MustChangePassword {
+		return "", nil, u.Constants.ErrMustChangePassword
+	}
+
+	token, err := generateSessionToken(u.Config.SessionTokenLength) // สร้างโทเคนแบบสุ่ม
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	session := &domain.Session{
+		ID:         hashSessionToken(token),      // เก็บเฉพาะแฮชของโทเคน
+		UserID:     user.ID,                      // ผู้ใช้ที่เป็นเจ้าของเซสชัน
+		CreatedAt:  now,                          // เวลาที่สร้างเซสชัน
+		LastSeenAt: now,                          // เวลาที่ใช้งานล่าสุด
+		ExpiresAt:  now.Add(u.Config.SessionTTL), // เวลาที่เซสชันหมดอายุ
+	}
+	if err := u.SessionRepo.Create(session); err != nil {
+		return "", nil, err // หากเกิดข้อผิดพลาดในการบันทึกเซสชันคืนค่าข้อผิดพลาด
+	}
+
+	u.Logger.Printf("Session issued for user: %s\n", user.Username) // บันทึกการสร้างเซสชันในล็อก
+	return token, session, nil
+}
+
+// AuthenticateSession ตรวจสอบโทเคนเซสชันและคืนค่าผู้ใช้ที่เป็นเจ้าของ
+func (u *UserUsecase) AuthenticateSession(token string) (*domain.User, error) {
+	session, err := u.SessionRepo.GetByID(hashSessionToken(token)) // ค้นหาเซสชันจากแฮชของโทเคน
+	if err != nil {
+		return nil, u.Constants.ErrSessionNotFound
+	}
+
+	now := time.Now()
+	if u.isSessionExpired(session, now) { // ตรวจสอบว่าเซสชันหมดอายุหรือไม่
+		_ = u.SessionRepo.Delete(session.ID) // ลบเซสชันที่หมดอายุแล้ว
+		return nil, u.Constants.ErrSessionExpired
+	}
+
+	user, err := u.UserRepo.GetByID(session.UserID) // ดึงข้อมูลผู้ใช้ที่เป็นเจ้าของเซสชัน
+	if err != nil {
+		return nil, u.Constants.ErrUserNotFound
+	}
+	if err := u.checkAccountStatus(user); err != nil { // บัญชีที่ถูกระงับหรือถูกลบใช้เซสชันเดิมไม่ได้
+		return nil, err
+	}
+	if user.MustChangePassword { // รหัสผ่านถูกรีเซ็ตหลังจากออกเซสชันนี้
+		return nil, u.Constants.ErrMustChangePassword
+	}
+
+	touched := *session      // คัดลอกก่อนแก้ไข เพราะ session เป็นข้อมูลที่ repository ใช้ร่วมกับผู้เรียกอื่น
+	touched.LastSeenAt = now // อัปเดตเวลาที่ใช้งานล่าสุด
+	if err := u.SessionRepo.Update(&touched); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RevokeSession ยกเลิกเซสชันตามโทเคนที่ระบุ
+func (u *UserUsecase) RevokeSession(token string) error {
+	if err := u.SessionRepo.Delete(hashSessionToken(token)); err != nil {
+		return u.Constants.ErrSessionNotFound // หากไม่พบเซสชันให้คืนค่าข้อผิดพลาด
+	}
+	return nil
+}
+
+// RevokeAllSessions ยกเลิกเซสชันและ refresh token ทั้งหมดของผู้ใช้
+func (u *UserUsecase) RevokeAllSessions(actor *domain.User, userID int64) error {
+	resource := domain.Resource{Kind: domain.ResourceUser, ID: userID, OwnerID: userID}
+	if err := u.Authorize(actor, domain.PermUserCredentials, resource); err != nil {
+		return err
+	}
+	if err := u.SessionRepo.DeleteByUserID(userID); err != nil {
+		return err
+	}
+	if u.RefreshRepo != nil { // refresh token ก็เป็นการเข้าสู่ระบบที่ยังค้างอยู่เช่นกัน
+		if err := u.RefreshRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+	}
+	u.Logger.Printf("All sessions revoked for user ID: %d\n", userID) // บันทึกการยกเลิกเซสชันในล็อก
+	return nil
+}
+
+// ListSessions คืนค่าเซสชันที่ยังใช้งานได้ทั้งหมดของผู้ใช้ และลบเซสชันที่หมดอายุแล้ว
+// ผู้ดำเนินการต้องมีสิทธิ์ PermUserCredentials กับผู้ใช้ userID
+func (u *UserUsecase) ListSessions(actor *domain.User, userID int64) ([]*domain.Session, error) {
+	resource := domain.Resource{Kind: domain.ResourceUser, ID: userID, OwnerID: userID}
+	if err := u.Authorize(actor, domain.PermUserCredentials, resource); err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.SessionRepo.GetByUserID(userID) // ดึงเซสชันทั้งหมดของผู้ใช้
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make([]*domain.Session, 0, len(sessions)) // สร้าง slice สำหรับเก็บเซสชันที่ยังใช้งานได้
+	for _, session := range sessions {
+		if u.isSessionExpired(session, now) {
+			_ = u.SessionRepo.Delete(session.ID) // ลบเซสชันที่หมดอายุแล้ว
+			continue
+		}
+		active = append(active, session)
+	}
+	return active, nil
+}
+
+// isSessionExpired ตรวจสอบว่าเซสชันหมดอายุตามเวลาสูงสุดหรือไม่มีการใช้งานเกินกำหนด
+func (u *UserUsecase) isSessionExpired(session *domain.Session, now time.Time) bool {
+	if now.After(session.ExpiresAt) { // เกินอายุสูงสุดของเซสชัน
+		return true
+	}
+	return u.Config.SessionIdleTimeout > 0 && now.Sub(session.LastSeenAt) > u.Config.SessionIdleTimeout // ไม่มีการใช้งานเกินกำหนด
+}
+
+// generateSessionToken สร้างโทเคนเซสชันแบบสุ่ม
+func generateSessionToken(length int) (string, error) {
+	token := make([]byte, length)               // สร้าง slice ของ byte ที่มีความยาวตามที่กำหนด
+	if _, err := rand.Read(token); err != nil { // อ่านข้อมูลแบบสุ่มลงใน token
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil // คืนค่าโทเคนที่เข้ารหัสเป็น base64 แบบ URL-safe
+}
+
+// hashSessionToken แฮชโทเคนเซสชันด้วย SHA-256 เพื่อใช้เป็นรหัสเซสชัน
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestAuthenticateSessionConcurrent(t *testing.T) {
+	u := newTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	token, _, err := u.IssueSession(alice)
+	if err != nil {
+		t.Fatalf("IssueSession error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ { // ตรวจพบการแก้ไขเซสชันที่ใช้ร่วมกันได้เมื่อรันด้วย -race
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := u.AuthenticateSession(token); err != nil {
+				t.Errorf("AuthenticateSession error = %v", err)
+			}
+			if _, err := u.ListSessions(alice, alice.ID); err != nil {
+				t.Errorf("ListSessions error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestListSessionsRequiresAuthorization(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	bob := mustCreateUser(t, u, "bobby", u.Constants.RoleUser)
+	if _, _, err := u.IssueSession(alice); err != nil {
+		t.Fatalf("IssueSession error = %v", err)
+	}
+
+	if _, err := u.ListSessions(bob, alice.ID); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("ListSessions by other user error = %v, want ErrPermissionDenied", err)
+	}
+	for _, actor := range []*domain.User{alice, admin} {
+		sessions, err := u.ListSessions(actor, alice.ID)
+		if err != nil || len(sessions) != 1 {
+			t.Errorf("ListSessions by %s = %d sessions, %v; want 1 session", actor.Username, len(sessions), err)
+		}
+	}
+}
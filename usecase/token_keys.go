@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// อัลกอริทึมที่รองรับสำหรับการลงลายมือชื่อ JWT
+const (
+	AlgHS256 = "HS256" // HMAC-SHA256 ใช้ secret ร่วมกัน
+	AlgEdDSA = "EdDSA" // Ed25519 ใช้คู่กุญแจสาธารณะ/ส่วนตัว
+)
+
+// minHMACSecretSize ขนาดต่ำสุดของ secret สำหรับ HS256 (256 bits เท่ากับขนาดผลลัพธ์ของ SHA-256)
+const minHMACSecretSize = 32
+
+// SigningKey แทนกุญแจที่ใช้ลงลายมือชื่อหรือตรวจสอบ JWT
+type SigningKey struct {
+	ID         string             // รหัสกุญแจ (kid) ที่ใส่ไว้ใน header ของ JWT
+	Algorithm  string             // อัลกอริทึมของกุญแจ เช่น "HS256" หรือ "EdDSA"
+	Secret     []byte             // secret สำหรับ HS256
+	PrivateKey ed25519.PrivateKey // กุญแจส่วนตัวสำหรับ EdDSA (ไม่มีหากใช้ตรวจสอบอย่างเดียว)
+	PublicKey  ed25519.PublicKey  // กุญแจสาธารณะสำหรับ EdDSA
+}
+
+// canSign ตรวจสอบว่ากุญแจนี้ใช้ลงลายมือชื่อได้หรือไม่
+func (k *SigningKey) canSign() bool {
+	switch k.Algorithm {
+	case AlgHS256:
+		return len(k.Secret) >= minHMACSecretSize
+	case AlgEdDSA:
+		return len(k.PrivateKey) == ed25519.PrivateKeySize
+	}
+	return false
+}
+
+// KeySet เก็บกุญแจทั้งหมดตาม kid พร้อมกุญแจที่ใช้งานอยู่สำหรับการออกโทเคนใหม่
+type KeySet struct {
+	mu       sync.RWMutex           // ใช้เพื่อควบคุมการเข้าถึงกุญแจอย่างปลอดภัยในหลายเธรด
+	keys     map[string]*SigningKey // map สำหรับเก็บกุญแจตาม kid
+	activeID string                 // kid ของกุญแจที่ใช้ลงลายมือชื่อโทเคนใหม่
+}
+
+// NewKeySet สร้าง KeySet ว่างใหม่
+func NewKeySet() *KeySet {
+	return &KeySet{keys: make(map[string]*SigningKey)}
+}
+
+// GenerateKey สร้างกุญแจใหม่แบบสุ่มตามอัลกอริทึมที่กำหนด
+func GenerateKey(id, algorithm string) (*SigningKey, error) {
+	switch algorithm {
+	case AlgHS256:
+		secret := make([]byte, minHMACSecretSize)    // สร้าง secret ขนาด 32 bytes
+		if _, err := rand.Read(secret); err != nil { // อ่านข้อมูลแบบสุ่มลงใน secret
+			return nil, err
+		}
+		return &SigningKey{ID: id, Algorithm: algorithm, Secret: secret}, nil
+	case AlgEdDSA:
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader) // สร้างคู่กุญแจ Ed25519
+		if err != nil {
+			return nil, err
+		}
+		return &SigningKey{ID: id, Algorithm: algorithm, PrivateKey: privateKey, PublicKey: publicKey}, nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+}
+
+// AddKey ตรวจสอบขนาดของกุญแจแล้วเพิ่มลงใน KeySet โดยกุญแจแรกที่ลงลายมือชื่อได้จะถูกตั้งเป็นกุญแจที่ใช้งาน
+func (ks *KeySet) AddKey(key *SigningKey) error {
+	if key.ID == "" {
+		return fmt.Errorf("signing key must have a kid")
+	}
+	switch key.Algorithm {
+	case AlgHS256:
+		if len(key.Secret) < minHMACSecretSize { // secret ที่สั้นหรือว่างทำให้ผู้อื่นปลอมลายมือชื่อได้
+			return fmt.Errorf("signing key %q: HS256 secret must be at least %d bytes", key.ID, minHMACSecretSize)
+		}
+	case AlgEdDSA:
+		if len(key.PrivateKey) != 0 && len(key.PrivateKey) != ed25519.PrivateKeySize {
+			return fmt.Errorf("signing key %q: invalid Ed25519 private key length %d", key.ID, len(key.PrivateKey))
+		}
+		if len(key.PublicKey) == 0 && len(key.PrivateKey) == ed25519.PrivateKeySize {
+			key.PublicKey = key.PrivateKey.Public().(ed25519.PublicKey) // สร้างกุญแจสาธารณะจากกุญแจส่วนตัว
+		}
+		if len(key.PublicKey) != ed25519.PublicKeySize { // ต้องมีกุญแจสาธารณะที่ถูกต้องสำหรับตรวจสอบลายมือชื่อ
+			return fmt.Errorf("signing key %q: invalid Ed25519 public key length %d", key.ID, len(key.PublicKey))
+		}
+	default:
+		return fmt.Errorf("unsupported signing algorithm: %s", key.Algorithm)
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if _, exists := ks.keys[key.ID]; exists { // ตรวจสอบว่ามี kid นี้อยู่แล้วหรือไม่
+		return fmt.Errorf("signing key %q already exists", key.ID)
+	}
+	ks.keys[key.ID] = key
+	if ks.activeID == "" && key.canSign() {
+		ks.activeID = key.ID
+	}
+	return nil
+}
+
+// SetActive เปลี่ยนกุญแจที่ใช้ลงลายมือชื่อโทเคนใหม่ (การหมุนเวียนกุญแจ) โดยกุญแจเดิมยังใช้ตรวจสอบโทเคนเก่าได้
+func (ks *KeySet) SetActive(id string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, exists := ks.keys[id]
+	if !exists {
+		return fmt.Errorf("signing key %q not found", id)
+	}
+	if !key.canSign() {
+		return fmt.Errorf("signing key %q cannot be used for signing", id)
+	}
+	ks.activeID = id
+	return nil
+}
+
+// RemoveKey ลบกุญแจที่เลิกใช้แล้ว โทเคนที่ลงลายมือชื่อด้วยกุญแจนี้จะตรวจสอบไม่ผ่านอีกต่อไป
+func (ks *KeySet) RemoveKey(id string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if id == ks.activeID { // ไม่อนุญาตให้ลบกุญแจที่ใช้งานอยู่
+		return fmt.Errorf("cannot remove active signing key %q", id)
+	}
+	delete(ks.keys, id)
+	return nil
+}
+
+// Active คืนค่ากุญแจที่ใช้ลงลายมือชื่อโทเคนใหม่
+func (ks *KeySet) Active() (*SigningKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, exists := ks.keys[ks.activeID]
+	if !exists {
+		return nil, fmt.Errorf("no active signing key")
+	}
+	return key, nil
+}
+
+// Get คืนค่ากุญแจตาม kid
+func (ks *KeySet) Get(id string) (*SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, exists := ks.keys[id]
+	return key, exists
+}
+
+// keyFile โครงสร้างของไฟล์กุญแจในรูปแบบ JSON
+type keyFile struct {
+	Active string `json:"active"` // kid ของกุญแจที่ใช้งาน
+	Keys   []struct {
+		ID         string `json:"kid"`                   // รหัสกุญแจ
+		Algorithm  string `json:"alg"`                   // อัลกอริทึม
+		Secret     string `json:"secret,omitempty"`      // secret สำหรับ HS256 (base64)
+		PrivateKey string `json:"private_key,omitempty"` // seed หรือกุญแจส่วนตัว Ed25519 (base64)
+		PublicKey  string `json:"public_key,omitempty"`  // กุญแจสาธารณะ Ed25519 (base64)
+	} `json:"keys"`
+}
+
+// LoadKeySet โหลดกุญแจจากไฟล์ JSON
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path) // อ่านไฟล์กุญแจ
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+	}
+
+	ks := NewKeySet()
+	for _, entry := range file.Keys {
+		key := &SigningKey{ID: entry.ID, Algorithm: entry.Algorithm}
+		if key.Secret, err = decodeKeyField(entry.Secret); err != nil {
+			return nil, fmt.Errorf("key %q: invalid secret: %w", entry.ID, err)
+		}
+		privateKey, err := decodeKeyField(entry.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid private_key: %w", entry.ID, err)
+		}
+		switch len(privateKey) {
+		case 0:
+		case ed25519.SeedSize:
+			key.PrivateKey = ed25519.NewKeyFromSeed(privateKey) // สร้างกุญแจส่วนตัวจาก seed
+		case ed25519.PrivateKeySize:
+			key.PrivateKey = ed25519.PrivateKey(privateKey)
+		default:
+			return nil, fmt.Errorf("key %q: invalid private_key length %d", entry.ID, len(privateKey))
+		}
+		publicKey, err := decodeKeyField(entry.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid public_key: %w", entry.ID, err)
+		}
+		if len(publicKey) > 0 {
+			key.PublicKey = ed25519.PublicKey(publicKey)
+		}
+		if err := ks.AddKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if file.Active != "" {
+		if err := ks.SetActive(file.Active); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// decodeKeyField ถอดรหัสค่า base64 ของฟิลด์ในไฟล์กุญแจ
+func decodeKeyField(value string) ([]byte, error) {
+	if value == "" {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(value)
+}
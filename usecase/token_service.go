@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ประเภทของโทเคนที่ออกโดย TokenService
+const (
+	TokenTypeAccess  = "access"  // access token สำหรับเรียกใช้บริการ
+	TokenTypeRefresh = "refresh" // refresh token สำหรับขอ access token ใหม่
+)
+
+// โครงสร้าง interface RefreshTokenRepository ใช้สำหรับเก็บสถานะของ refresh token
+type RefreshTokenRepository interface {
+	Create(token *domain.RefreshToken) error               // บันทึก refresh token ใหม่
+	Consume(id string) (*domain.RefreshToken, bool, error) // ทำเครื่องหมายว่าโทเคนถูกใช้แล้ว และคืนค่าว่าเป็นการใช้ครั้งแรกหรือไม่
+	RevokeFamily(family string) error                      // ยกเลิกโทเคนทั้งกลุ่ม
+	RevokeByUserID(userID int64) error                     // ยกเลิกโทเคนทั้งหมดของผู้ใช้
+}
+
+// TokenClaims ข้อมูลที่เก็บไว้ใน JWT
+type TokenClaims struct {
+	Subject   string `json:"sub"`           // รหัสประจำตัวผู้ใช้ในรูปแบบข้อความ
+	UserID    int64  `json:"uid"`           // รหัสประจำตัวผู้ใช้
+	Username  string `json:"username"`      // ชื่อผู้ใช้
+	Role      string `json:"role"`          // บทบาทผู้ใช้
+	Type      string `json:"typ"`           // ประเภทของโทเคน ("access" หรือ "refresh")
+	ID        string `json:"jti"`           // รหัสของโทเคน
+	Family    string `json:"fam,omitempty"` // รหัสกลุ่มของ refresh token
+	Issuer    string `json:"iss,omitempty"` // ผู้ออกโทเคน
+	IssuedAt  int64  `json:"iat"`           // เวลาที่ออกโทเคน (Unix)
+	ExpiresAt int64  `json:"exp"`           // เวลาที่โทเคนหมดอายุ (Unix)
+}
+
+// TokenPair คู่ของ access token และ refresh token ที่ออกให้ผู้ใช้
+type TokenPair struct {
+	AccessToken  string    // access token
+	RefreshToken string    // refresh token
+	ExpiresAt    time.Time // เวลาที่ access token หมดอายุ
+}
+
+// jwtHeader ส่วน header ของ JWT
+type jwtHeader struct {
+	Algorithm string `json:"alg"` // อัลกอริทึมที่ใช้ลงลายมือชื่อ
+	Type      string `json:"typ"` // ชนิดของโทเคน (JWT)
+	KeyID     string `json:"kid"` // รหัสกุญแจที่ใช้ลงลายมือชื่อ
+}
+
+// TokenService ออกและตรวจสอบ JWT สำหรับผู้ใช้ที่เข้าสู่ระบบแล้ว
+type TokenService struct {
+	Keys        *KeySet                // กุญแจสำหรับลงลายมือชื่อและตรวจสอบโทเคน
+	RefreshRepo RefreshTokenRepository // ที่เก็บสถานะของ refresh token
+	UserRepo    UserRepository         // ใช้ดึงข้อมูลผู้ใช้ล่าสุดตอนหมุนเวียนโทเคน
+	Config      *Config                // การตั้งค่าอายุโทเคนและผู้ออกโทเคน
+	Logger      *log.Logger            // ใช้สำหรับการเขียนล็อก
+	Constants   *Constants             // ค่าคงที่และข้อผิดพลาดที่ใช้ในระบบ
+}
+
+// NewTokenService สร้างและคืนค่า TokenService ใหม่
+func NewTokenService(keys *KeySet, refreshRepo RefreshTokenRepository, userRepo UserRepository, config *Config, logger *log.Logger, constants *Constants) *TokenService {
+	return &TokenService{
+		Keys:        keys,
+		RefreshRepo: refreshRepo,
+		UserRepo:    userRepo,
+		Config:      config,
+		Logger:      logger,
+		Constants:   constants,
+	}
+}
+
+// IssueTokens ออก access token และ refresh token ชุดใหม่ให้ผู้ใช้ที่ผ่าน Login แล้ว
+// ผู้ใช้ที่ต้องเปลี่ยนรหัสผ่านจะไม่ได้รับโทเคนจนกว่าจะเปลี่ยนรหัสผ่านผ่าน CompletePasswordChange
+func (s *TokenService) IssueTokens(user *domain.User) (*TokenPair, error) {
+	if err := accountStatusError(user, s.Constants); err != nil { // บัญชีที่ไม่ได้อยู่ในสถานะใช้งานรับโทเคนไม่ได้
+		return nil, err
+	}
+	if user.MustChangePassword {
+		return nil, s.Constants.ErrMustChangePassword
+	}
+	family, err := generateTokenID() // สร้างกลุ่มใหม่สำหรับการเข้าสู่ระบบครั้งนี้
+	if err != nil {
+		return nil, err
+	}
+	return s.issuePair(user, family)
+}
+
+// Refresh แลก refresh token เป็นโทเคนชุดใหม่ หาก refresh token เคยถูกใช้แล้วจะยกเลิกโทเคนทั้งกลุ่ม
+func (s *TokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	claims, err := s.parse(refreshToken, TokenTypeRefresh) // ตรวจสอบลายมือชื่อและอายุของ refresh token
+	if err != nil {
+		return nil, err
+	}
+
+	record, firstUse, err := s.RefreshRepo.Consume(claims.ID) // ทำเครื่องหมายว่าโทเคนถูกใช้แล้ว
+	if err != nil {
+		return nil, s.Constants.ErrInvalidToken
+	}
+	if record.Revoked {
+		return nil, s.Constants.ErrInvalidToken // โทเคนถูกยกเลิกแล้ว
+	}
+	if !firstUse { // พบการนำโทเคนที่ใช้แล้วกลับมาใช้ซ้ำ
+		if err := s.RefreshRepo.RevokeFamily(record.Family); err != nil {
+			return nil, err
+		}
+		s.Logger.Printf("Refresh token reuse detected for user ID: %d, family revoked\n", record.UserID) // บันทึกการใช้ซ้ำในล็อก
+		return nil, s.Constants.ErrTokenReused
+	}
+
+	user, err := s.UserRepo.GetByID(record.UserID) // ดึงข้อมูลผู้ใช้ล่าสุด เช่น บทบาทที่อาจเปลี่ยนไป
+	if err != nil {
+		return nil, s.Constants.ErrUserNotFound
+	}
+	if err := accountStatusError(user, s.Constants); err != nil { // บัญชีถูกระงับหรือถูกลบหลังจากออกโทเคนกลุ่มนี้
+		return nil, err
+	}
+	if user.MustChangePassword { // รหัสผ่านถูกรีเซ็ตหลังจากออกโทเคนกลุ่มนี้
+		return nil, s.Constants.ErrMustChangePassword
+	}
+	return s.issuePair(user, record.Family)
+}
+
+// RevokeRefreshToken ยกเลิก refresh token และโทเคนทั้งกลุ่มเดียวกัน (เช่น เมื่อออกจากระบบ)
+func (s *TokenService) RevokeRefreshToken(refreshToken string) error {
+	claims, err := s.parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return err
+	}
+	return s.RefreshRepo.RevokeFamily(claims.Family)
+}
+
+// VerifyToken ตรวจสอบ access token และคืนค่า claims ที่อยู่ในโทเคน
+func (s *TokenService) VerifyToken(token string) (*TokenClaims, error) {
+	return s.parse(token, TokenTypeAccess)
+}
+
+// issuePair ออก access token และ refresh token ในกลุ่มที่กำหนด
+func (s *TokenService) issuePair(user *domain.User, family string) (*TokenPair, error) {
+	now := time.Now()
+	accessExpiresAt := now.Add(s.Config.AccessTokenTTL)
+	refreshExpiresAt := now.Add(s.Config.RefreshTokenTTL)
+
+	accessID, err := generateTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshID, err := generateTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.sign(s.newClaims(user, TokenTypeAccess, accessID, "", now, accessExpiresAt))
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.sign(s.newClaims(user, TokenTypeRefresh, refreshID, family, now, refreshExpiresAt))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.RefreshRepo.Create(&domain.RefreshToken{
+		ID:        refreshID,
+		Family:    family,
+		UserID:    user.ID,
+		IssuedAt:  now,
+		ExpiresAt: refreshExpiresAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresAt: accessExpiresAt}, nil
+}
+
+// newClaims สร้าง claims สำหรับโทเคนของผู้ใช้
+func (s *TokenService) newClaims(user *domain.User, tokenType, id, family string, issuedAt, expiresAt time.Time) *TokenClaims {
+	return &TokenClaims{
+		Subject:   strconv.FormatInt(user.ID, 10),
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		Type:      tokenType,
+		ID:        id,
+		Family:    family,
+		Issuer:    s.Config.TokenIssuer,
+		IssuedAt:  issuedAt.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+}
+
+// sign ลงลายมือชื่อ claims ด้วยกุญแจที่ใช้งานอยู่และคืนค่า JWT
+func (s *TokenService) sign(claims *TokenClaims) (string, error) {
+	key, err := s.Keys.Active()
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(jwtHeader{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	var signature []byte
+	switch key.Algorithm {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.Secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case AlgEdDSA:
+		signature = ed25519.Sign(key.PrivateKey, []byte(signingInput))
+	default:
+		return "", fmt.Errorf("unsupported signing algorithm: %s", key.Algorithm)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parse ตรวจสอบลายมือชื่อ อายุ และประเภทของโทเคน แล้วคืนค่า claims
+func (s *TokenService) parse(token, tokenType string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".") // JWT ประกอบด้วย header.payload.signature
+	if len(parts) != 3 {
+		return nil, s.Constants.ErrInvalidToken
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, s.Constants.ErrInvalidToken
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, s.Constants.ErrInvalidToken
+	}
+
+	key, exists := s.Keys.Get(header.KeyID) // ค้นหากุญแจตาม kid
+	if !exists || key.Algorithm != header.Algorithm {
+		return nil, s.Constants.ErrInvalidToken // ไม่รู้จักกุญแจหรืออัลกอริทึมไม่ตรงกับกุญแจ
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, s.Constants.ErrInvalidToken
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	switch key.Algorithm {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.Secret)
+		mac.Write(signingInput)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, s.Constants.ErrInvalidToken
+		}
+	case AlgEdDSA:
+		if len(key.PublicKey) != ed25519.PublicKeySize || !ed25519.Verify(key.PublicKey, signingInput, signature) {
+			return nil, s.Constants.ErrInvalidToken
+		}
+	default:
+		return nil, s.Constants.ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, s.Constants.ErrInvalidToken
+	}
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, s.Constants.ErrInvalidToken
+	}
+
+	if claims.Type != tokenType || (s.Config.TokenIssuer != "" && claims.Issuer != s.Config.TokenIssuer) {
+		return nil, s.Constants.ErrInvalidToken // ประเภทหรือผู้ออกโทเคนไม่ตรงกัน
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, s.Constants.ErrTokenExpired // โทเคนหมดอายุแล้ว
+	}
+	return &claims, nil
+}
+
+// generateTokenID สร้างรหัสโทเคนแบบสุ่ม
+func generateTokenID() (string, error) {
+	id := make([]byte, 16)                   // สร้าง slice ของ byte ขนาด 16 bytes
+	if _, err := rand.Read(id); err != nil { // อ่านข้อมูลแบบสุ่มลงใน id
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
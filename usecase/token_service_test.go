@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"Basic_login/repository"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTestTokenService สร้าง TokenService ที่ใช้ UserRepo เดียวกับ u และกุญแจ HS256 ชื่อ "k1"
+// โดยกำหนด RefreshRepo ให้ u ด้วย เพื่อให้การยกเลิกเซสชันยกเลิก refresh token ด้วย
+func newTestTokenService(t *testing.T, u *UserUsecase) *TokenService {
+	t.Helper()
+	keys := NewKeySet()
+	key, err := GenerateKey("k1", AlgHS256)
+	if err != nil {
+		t.Fatalf("GenerateKey error = %v", err)
+	}
+	if err := keys.AddKey(key); err != nil {
+		t.Fatalf("AddKey error = %v", err)
+	}
+	refreshRepo := repository.NewInMemoryRefreshTokenRepository()
+	u.RefreshRepo = refreshRepo
+	return NewTokenService(keys, refreshRepo, u.UserRepo, u.Config, u.Logger, u.Constants)
+}
+
+func TestTokenSignAndVerify(t *testing.T) {
+	u := newTestUsecase(t)
+	service := newTestTokenService(t, u)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	pair, err := service.IssueTokens(alice)
+	if err != nil {
+		t.Fatalf("IssueTokens error = %v", err)
+	}
+	claims, err := service.VerifyToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyToken error = %v", err)
+	}
+	if claims.UserID != alice.ID || claims.Username != "alice" || claims.Type != TokenTypeAccess {
+		t.Errorf("claims = %+v, want access token for alice", claims)
+	}
+
+	if _, err := service.VerifyToken(pair.RefreshToken); !errors.Is(err, u.Constants.ErrInvalidToken) {
+		t.Errorf("VerifyToken(refresh token) error = %v, want ErrInvalidToken", err)
+	}
+	parts := strings.Split(pair.AccessToken, ".")
+	tampered := parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2]))
+	if _, err := service.VerifyToken(tampered); !errors.Is(err, u.Constants.ErrInvalidToken) {
+		t.Errorf("VerifyToken(tampered) error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestTokenKeyRotation(t *testing.T) {
+	u := newTestUsecase(t)
+	service := newTestTokenService(t, u)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	before, err := service.IssueTokens(alice)
+	if err != nil {
+		t.Fatalf("IssueTokens error = %v", err)
+	}
+	next, err := GenerateKey("k2", AlgEdDSA)
+	if err != nil {
+		t.Fatalf("GenerateKey error = %v", err)
+	}
+	if err := service.Keys.AddKey(next); err != nil {
+		t.Fatalf("AddKey error = %v", err)
+	}
+	if err := service.Keys.SetActive("k2"); err != nil {
+		t.Fatalf("SetActive error = %v", err)
+	}
+	after, err := service.IssueTokens(alice)
+	if err != nil {
+		t.Fatalf("IssueTokens after rotation error = %v", err)
+	}
+
+	for name, token := range map[string]string{"old key": before.AccessToken, "new key": after.AccessToken} {
+		if _, err := service.VerifyToken(token); err != nil {
+			t.Errorf("VerifyToken(%s) error = %v", name, err)
+		}
+	}
+
+	if err := service.Keys.RemoveKey("k1"); err != nil {
+		t.Fatalf("RemoveKey error = %v", err)
+	}
+	if _, err := service.VerifyToken(before.AccessToken); !errors.Is(err, u.Constants.ErrInvalidToken) {
+		t.Errorf("VerifyToken(removed key) error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := service.VerifyToken(after.AccessToken); err != nil {
+		t.Errorf("VerifyToken(active key) error = %v", err)
+	}
+}
+
+func TestAddKeyRejectsWeakKeys(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey error = %v", err)
+	}
+	tests := []struct {
+		name string
+		key  *SigningKey
+		ok   bool
+	}{
+		{"HS256 without secret", &SigningKey{ID: "x", Algorithm: AlgHS256}, false},
+		{"HS256 short secret", &SigningKey{ID: "x", Algorithm: AlgHS256, Secret: make([]byte, 16)}, false},
+		{"HS256 32-byte secret", &SigningKey{ID: "x", Algorithm: AlgHS256, Secret: make([]byte, 32)}, true},
+		{"EdDSA without keys", &SigningKey{ID: "x", Algorithm: AlgEdDSA}, false},
+		{"EdDSA short public key", &SigningKey{ID: "x", Algorithm: AlgEdDSA, PublicKey: publicKey[:16]}, false},
+		{"EdDSA public key only", &SigningKey{ID: "x", Algorithm: AlgEdDSA, PublicKey: publicKey}, true},
+		{"EdDSA private key only", &SigningKey{ID: "x", Algorithm: AlgEdDSA, PrivateKey: privateKey}, true},
+	}
+	for _, tt := range tests {
+		err := NewKeySet().AddKey(tt.key)
+		if tt.ok && err != nil {
+			t.Errorf("%s: AddKey error = %v, want nil", tt.name, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: AddKey error = nil, want error", tt.name)
+		}
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	u := newTestUsecase(t)
+	service := newTestTokenService(t, u)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	first, err := service.IssueTokens(alice)
+	if err != nil {
+		t.Fatalf("IssueTokens error = %v", err)
+	}
+	second, err := service.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh error = %v", err)
+	}
+	if _, err := service.Refresh(first.RefreshToken); !errors.Is(err, u.Constants.ErrTokenReused) {
+		t.Fatalf("Refresh(reused) error = %v, want ErrTokenReused", err)
+	}
+	if _, err := service.Refresh(second.RefreshToken); !errors.Is(err, u.Constants.ErrInvalidToken) {
+		t.Errorf("Refresh(after family revoked) error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRefreshRejectsDisabledAccount(t *testing.T) {
+	u := newTestUsecase(t)
+	service := newTestTokenService(t, u)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	pair, err := service.IssueTokens(alice)
+	if err != nil {
+		t.Fatalf("IssueTokens error = %v", err)
+	}
+	updated := *mustGetUser(t, u, alice.ID)
+	updated.Status = domain.StatusDisabled
+	if err := u.UserRepo.Update(&updated); err != nil { // เปลี่ยนสถานะโดยตรง เพื่อทดสอบการตรวจสอบใน Refresh โดยไม่พึ่งการยกเลิกโทเคน
+		t.Fatalf("Update error = %v", err)
+	}
+	if _, err := service.Refresh(pair.RefreshToken); !errors.Is(err, u.Constants.ErrAccountDisabled) {
+		t.Errorf("Refresh error = %v, want ErrAccountDisabled", err)
+	}
+}
+
+func TestRevokeAllSessionsRevokesRefreshTokens(t *testing.T) {
+	u := newTestUsecase(t)
+	service := newTestTokenService(t, u)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	pair, err := service.IssueTokens(alice)
+	if err != nil {
+		t.Fatalf("IssueTokens error = %v", err)
+	}
+	if err := u.RevokeAllSessions(alice, alice.ID); err != nil {
+		t.Fatalf("RevokeAllSessions error = %v", err)
+	}
+	if _, err := service.Refresh(pair.RefreshToken); !errors.Is(err, u.Constants.ErrInvalidToken) {
+		t.Errorf("Refresh error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestChangePasswordRevokesRefreshTokens(t *testing.T) {
+	u := newTestUsecase(t)
+	service := newTestTokenService(t, u)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	pair, err := service.IssueTokens(alice)
+	if err != nil {
+		t.Fatalf("IssueTokens error = %v", err)
+	}
+	if err := u.ChangePassword(alice, alice.ID, testPassword, "N3w!Passw0rd#yz"); err != nil {
+		t.Fatalf("ChangePassword error = %v", err)
+	}
+	if _, err := service.Refresh(pair.RefreshToken); !errors.Is(err, u.Constants.ErrInvalidToken) {
+		t.Errorf("Refresh error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestAccountChangesRevokeRefreshTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(u *UserUsecase, admin *domain.User, targetID int64) error
+	}{
+		{"disable", func(u *UserUsecase, admin *domain.User, targetID int64) error {
+			if err := u.DisableUser(admin, targetID); err != nil {
+				return err
+			}
+			return u.EnableUser(admin, targetID) // เปิดใช้งานอีกครั้ง โทเคนเดิมต้องยังใช้ไม่ได้
+		}},
+		{"hard delete", func(u *UserUsecase, admin *domain.User, targetID int64) error {
+			return u.HardDeleteUser(admin, targetID)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t)
+			service := newTestTokenService(t, u)
+			admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+			alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+			pair, err := service.IssueTokens(alice)
+			if err != nil {
+				t.Fatalf("IssueTokens error = %v", err)
+			}
+			if err := tt.revoke(u, admin, alice.ID); err != nil {
+				t.Fatalf("%s error = %v", tt.name, err)
+			}
+			if _, err := service.Refresh(pair.RefreshToken); !errors.Is(err, u.Constants.ErrInvalidToken) {
+				t.Errorf("Refresh error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
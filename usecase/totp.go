@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// totpSecretLength ความยาวของ secret ของ TOTP เป็น bytes (160 bits ตามที่ RFC 4226 แนะนำ)
+const totpSecretLength = 20
+
+// totpEncoding การเข้ารหัส base32 แบบไม่มี padding ที่แอปยืนยันตัวตนใช้
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// SecondFactorRequiredError คืนค่าจาก Login เมื่อรหัสผ่านถูกต้องแต่ผู้ใช้เปิดใช้การยืนยันตัวตนสองขั้นตอน
+// ผู้เรียกต้องนำ Challenge ไปเรียก CompleteSecondFactor พร้อมรหัส TOTP
+type SecondFactorRequiredError struct {
+	Challenge string // โทเคนที่ใช้ยืนยันขั้นตอนที่สอง มีอายุตาม Config.SecondFactorTTL
+	reason    error  // Constants.ErrSecondFactorRequired
+}
+
+// Error คืนค่าข้อความของข้อผิดพลาด
+func (e *SecondFactorRequiredError) Error() string {
+	return e.reason.Error()
+}
+
+// Unwrap ให้ errors.Is ตรวจสอบกับ Constants.ErrSecondFactorRequired ได้
+func (e *SecondFactorRequiredError) Unwrap() error {
+	return e.reason
+}
+
+// BeginTOTPEnrollment สร้าง secret ใหม่สำหรับ TOTP และคืนค่า secret (base32) พร้อม URI otpauth:// สำหรับสร้าง QR code
+// secret จะยังไม่ถูกใช้จนกว่าจะยืนยันด้วย ConfirmTOTPEnrollment ซึ่งใช้สำหรับการลงทะเบียนใหม่ได้ด้วย
+// หากผู้ใช้เปิดใช้ TOTP อยู่แล้ว ต้องยืนยันด้วยรหัส code จาก secret เดิม เพื่อไม่ให้ผู้ที่มีเพียงรหัสผ่านหรือเซสชันเปลี่ยนอุปกรณ์ได้
+// โดยรหัสผิดนับเป็นการยืนยันตัวตนไม่สำเร็จ (ผู้ใช้ที่ยังไม่เปิดใช้ TOTP ไม่ต้องระบุ code)
+func (u *UserUsecase) BeginTOTPEnrollment(actor *domain.User, userID int64, code string) (string, string, error) {
+	user, err := u.UserRepo.GetByID(userID) // ดึงข้อมูลผู้ใช้ตาม ID
+	if err != nil {
+		return "", "", u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserCredentials, domain.UserResource(user)); err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		if _, err := u.beginLoginAttempt(user, time.Now()); err != nil { // ใช้การล็อกบัญชีเดียวกับการตรวจสอบรหัสผ่าน
+			return "", "", err
+		}
+	}
+
+	secret := make([]byte, totpSecretLength)     // สร้าง secret แบบสุ่ม
+	if _, err := rand.Read(secret); err != nil { // อ่านข้อมูลแบบสุ่มลงใน secret
+		return "", "", err
+	}
+	encrypted, err := encryptSecret(u.Config.SecretKey, secret) // เข้ารหัส secret ก่อนเก็บ
+	if err != nil {
+		return "", "", err
+	}
+
+	updated, err := u.modifyUser(user.ID, func(updated *domain.User) error {
+		if updated.TOTPEnabled { // ตรวจสอบรหัสจาก secret เดิมภายใต้ล็อก เพื่อไม่ให้ใช้รหัสซ้ำได้
+			if err := u.useTOTPCode(updated, code); err != nil {
+				return err
+			}
+		}
+		updated.TOTPPendingSecret = encrypted // เก็บไว้รอการยืนยัน
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		u.resetFailedLogins(updated) // ยืนยันรหัสสำเร็จ ล้างจำนวนครั้งที่ไม่สำเร็จ
+	}
+
+	encodedSecret := totpEncoding.EncodeToString(secret)
+	return encodedSecret, u.totpURI(user.Username, encodedSecret), nil
+}
+
+// ConfirmTOTPEnrollment ยืนยัน secret ที่รอการยืนยันด้วยรหัส TOTP จากแอป และเปิดใช้การยืนยันตัวตนสองขั้นตอน
+// ผู้ดำเนินการต้องมีสิทธิ์ PermUserCredentials กับผู้ใช้ userID
+func (u *UserUsecase) ConfirmTOTPEnrollment(actor *domain.User, userID int64, code string) error {
+	user, err := u.UserRepo.GetByID(userID)
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserCredentials, domain.UserResource(user)); err != nil {
+		return err
+	}
+
+	_, err = u.modifyUser(userID, func(updated *domain.User) error {
+		if len(updated.TOTPPendingSecret) == 0 { // ยังไม่ได้เริ่มลงทะเบียน
+			return u.Constants.ErrTOTPNotPending
+		}
+		secret, err := decryptSecret(u.Config.SecretKey, updated.TOTPPendingSecret)
+		if err != nil {
+			return err
+		}
+		counter, ok := u.verifyTOTP(secret, code, 0, time.Now())
+		if !ok {
+			return u.Constants.ErrInvalidTOTPCode
+		}
+
+		updated.TOTPSecret = updated.TOTPPendingSecret // ย้าย secret ที่ยืนยันแล้วมาใช้งาน
+		updated.TOTPPendingSecret = nil
+		updated.TOTPEnabled = true
+		updated.TOTPLastCounter = counter // รหัสที่ใช้ยืนยันแล้วจะใช้ซ้ำไม่ได้
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	u.Logger.Printf("TOTP enabled: %s\n", user.Username) // บันทึกการเปิดใช้ TOTP ในล็อก
+	return nil
+}
+
+// DisableTOTP ปิดการยืนยันตัวตนสองขั้นตอนและล้างรหัสกู้คืน โดยต้องยืนยันด้วยรหัส TOTP ปัจจุบัน
+// ผู้ดำเนินการต้องมีสิทธิ์ PermUserCredentials กับผู้ใช้ userID
+func (u *UserUsecase) DisableTOTP(actor *domain.User, userID int64, code string) error {
+	user, err := u.UserRepo.GetByID(userID)
+	if err != nil {
+		return u.Constants.ErrUserNotFound
+	}
+	if err := u.Authorize(actor, domain.PermUserCredentials, domain.UserResource(user)); err != nil {
+		return err
+	}
+	if _, err := u.beginLoginAttempt(user, time.Now()); err != nil { // รหัสผิดนับเป็นการยืนยันตัวตนไม่สำเร็จ
+		return err
+	}
+
+	updated, err := u.modifyUser(userID, func(updated *domain.User) error {
+		if !updated.TOTPEnabled {
+			return u.Constants.ErrTOTPNotEnabled
+		}
+		if err := u.useTOTPCode(updated, code); err != nil {
+			return err
+		}
+
+		updated.TOTPEnabled = false
+		updated.TOTPSecret = nil
+		updated.TOTPPendingSecret = nil
+		updated.TOTPLastCounter = 0
+		updated.RecoveryCodes = nil // รหัสกู้คืนใช้แทนรหัส TOTP ได้ จึงต้องถูกล้างไปพร้อมกัน
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	u.resetFailedLogins(updated)
+
+	u.Logger.Printf("TOTP disabled: %s\n", user.Username) // บันทึกการปิดใช้ TOTP ในล็อก
+	return nil
+}
+
+// CompleteSecondFactor ยืนยันขั้นตอนที่สองของการเข้าสู่ระบบด้วย challenge จาก Login และรหัส TOTP
+func (u *UserUsecase) CompleteSecondFactor(challenge, code string) (*domain.User, error) {
+	user, err := u.userFromChallenge(challengeSecondFactor, challenge) // ตรวจสอบ challenge และดึงข้อมูลผู้ใช้
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkAccountStatus(user); err != nil { // บัญชีอาจถูกระงับหลังจากออก challenge
+		return nil, u.loginFailure(user.Username, err)
+	}
+
+	if _, err := u.beginLoginAttempt(user, time.Now()); err != nil { // ใช้การล็อกบัญชีเดียวกับการตรวจสอบรหัสผ่าน
+		return nil, u.loginFailure(user.Username, err)
+	}
+
+	updated, err := u.checkTOTP(user, code)
+	if err != nil { // รหัสผิดถูกนับเป็นการเข้าสู่ระบบไม่สำเร็จไว้แล้ว
+		return nil, u.loginFailure(user.Username, err)
+	}
+
+	return u.completeLogin(u.resetFailedLogins(updated))
+}
+
+// checkTOTP ตรวจสอบรหัส TOTP ของผู้ใช้และบันทึก counter ที่ใช้ล่าสุด โดยถือล็อกของผู้ใช้ไว้
+// เพื่อไม่ให้รหัสเดียวกันถูกใช้สำเร็จสองครั้งจากการเรียกพร้อมกัน
+func (u *UserUsecase) checkTOTP(user *domain.User, code string) (*domain.User, error) {
+	return u.modifyUser(user.ID, func(updated *domain.User) error {
+		return u.useTOTPCode(updated, code)
+	})
+}
+
+// useTOTPCode ตรวจสอบรหัส TOTP กับ secret ของ user ป้องกันการใช้รหัสซ้ำ และกำหนด counter ที่ใช้ล่าสุดให้ user
+func (u *UserUsecase) useTOTPCode(user *domain.User, code string) error {
+	secret, err := decryptSecret(u.Config.SecretKey, user.TOTPSecret)
+	if err != nil {
+		return err
+	}
+
+	counter, ok := u.verifyTOTP(secret, code, user.TOTPLastCounter, time.Now())
+	if !ok {
+		return u.Constants.ErrInvalidTOTPCode
+	}
+	user.TOTPLastCounter = counter // บันทึก counter เพื่อไม่ให้ใช้รหัสเดิมซ้ำ
+	return nil
+}
+
+// verifyTOTP ตรวจสอบรหัสภายในช่วงเวลาที่คลาดเคลื่อนได้ตาม Config.TOTPSkew
+// โดยรหัสต้องมาจาก counter ที่มากกว่า lastCounter และคืนค่า counter ที่ตรงกัน
+func (u *UserUsecase) verifyTOTP(secret []byte, code string, lastCounter int64, now time.Time) (int64, bool) {
+	if len(code) != u.Config.TOTPDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(u.Config.TOTPPeriod/time.Second) // counter ของช่วงเวลาปัจจุบัน
+	for offset := -int64(u.Config.TOTPSkew); offset <= int64(u.Config.TOTPSkew); offset++ {
+		counter := current + offset
+		if counter <= lastCounter { // รหัสของช่วงเวลาที่เคยใช้แล้วใช้ซ้ำไม่ได้
+			continue
+		}
+		expected := generateTOTP(secret, counter, u.Config.TOTPDigits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// generateTOTP สร้างรหัส HOTP ตาม RFC 4226 สำหรับ counter ที่กำหนด (RFC 6238 ใช้เวลาเป็น counter)
+func generateTOTP(secret []byte, counter int64, digits int) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f // dynamic truncation
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulus)
+}
+
+// totpURI สร้าง URI otpauth:// สำหรับแอปยืนยันตัวตน
+func (u *UserUsecase) totpURI(username, encodedSecret string) string {
+	query := url.Values{}
+	query.Set("secret", encodedSecret)
+	query.Set("issuer", u.Config.TOTPIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(u.Config.TOTPDigits))
+	query.Set("period", strconv.Itoa(int(u.Config.TOTPPeriod/time.Second)))
+
+	label := url.PathEscape(u.Config.TOTPIssuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// วัตถุประสงค์ของ challenge ซึ่งรวมอยู่ในข้อความที่ลงลายมือชื่อ เพื่อไม่ให้นำ challenge ไปใช้ผิดขั้นตอน
+const (
+	challengeSecondFactor   = "2fa" // ขั้นตอนที่สองของการเข้าสู่ระบบ
+	challengePasswordChange = "pwd" // การเปลี่ยนรหัสผ่านที่ถูกบังคับก่อนเข้าสู่ระบบ
+)
+
+// secondFactorRequired สร้าง challenge ที่ลงลายมือชื่อแล้วสำหรับขั้นตอนที่สองของการเข้าสู่ระบบ
+func (u *UserUsecase) secondFactorRequired(user *domain.User) error {
+	challenge, err := u.newChallenge(challengeSecondFactor, user)
+	if err != nil {
+		return err
+	}
+	u.Logger.Printf("Second factor required: %s\n", user.Username) // บันทึกว่าต้องยืนยันขั้นตอนที่สองในล็อก
+	return &SecondFactorRequiredError{
+		Challenge: challenge,
+		reason:    u.Constants.ErrSecondFactorRequired,
+	}
+}
+
+// newChallenge สร้าง challenge ในรูปแบบ userID.expiresAt.signature สำหรับวัตถุประสงค์ purpose
+// โดยมีอายุตาม Config.SecondFactorTTL และคืนค่าข้อผิดพลาดหาก Config.SecretKey ไม่ได้กำหนดหรือมีขนาดไม่ถูกต้อง
+func (u *UserUsecase) newChallenge(purpose string, user *domain.User) (string, error) {
+	expiresAt := time.Now().Add(u.Config.SecondFactorTTL).Unix()
+	payload := strconv.FormatInt(user.ID, 10) + "." + strconv.FormatInt(expiresAt, 10)
+	signature, err := signMessage(u.Config.SecretKey, []byte(purpose+"."+payload))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// userFromChallenge ตรวจสอบลายมือชื่อ วัตถุประสงค์ และอายุของ challenge แล้วคืนค่าผู้ใช้
+func (u *UserUsecase) userFromChallenge(purpose, challenge string) (*domain.User, error) {
+	parts := strings.Split(challenge, ".") // challenge ประกอบด้วย userID.expiresAt.signature
+	if len(parts) != 3 {
+		return nil, u.Constants.ErrInvalidChallenge
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, u.Constants.ErrInvalidChallenge
+	}
+	expected, err := signMessage(u.Config.SecretKey, []byte(purpose+"."+parts[0]+"."+parts[1]))
+	if err != nil || !hmac.Equal(signature, expected) { // กุญแจไม่ถูกต้องหรือลายมือชื่อไม่ตรงกัน
+		return nil, u.Constants.ErrInvalidChallenge
+	}
+
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, u.Constants.ErrInvalidChallenge
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt { // challenge หมดอายุแล้ว
+		return nil, u.Constants.ErrInvalidChallenge
+	}
+
+	user, err := u.UserRepo.GetByID(userID)
+	if err != nil {
+		return nil, u.Constants.ErrUserNotFound
+	}
+	return user, nil
+}
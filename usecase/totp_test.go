@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGenerateTOTPRFC6238Vectors(t *testing.T) {
+	secret := []byte("12345678901234567890") // secret ของชุดทดสอบ SHA-1 ใน RFC 6238 ภาคผนวก B
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		if got := generateTOTP(secret, tt.unix/30, 8); got != tt.want {
+			t.Errorf("generateTOTP(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+// enrollTOTP เปิดใช้ TOTP ให้ผู้ใช้ userID และคืนค่า secret โดยยืนยันด้วยรหัสของช่วงเวลาก่อนหน้า
+// เพื่อให้รหัสของช่วงเวลาปัจจุบันยังใช้เข้าสู่ระบบได้
+func enrollTOTP(t *testing.T, u *UserUsecase, userID int64) []byte {
+	t.Helper()
+	user := mustGetUser(t, u, userID)
+	encoded, _, err := u.BeginTOTPEnrollment(user, userID, "")
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment error = %v", err)
+	}
+	secret, err := totpEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("decode secret error = %v", err)
+	}
+	if err := u.ConfirmTOTPEnrollment(user, userID, generateTOTP(secret, currentTOTPCounter(u)-1, u.Config.TOTPDigits)); err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment error = %v", err)
+	}
+	return secret
+}
+
+// currentTOTPCounter คืนค่า counter ของช่วงเวลาปัจจุบัน
+func currentTOTPCounter(u *UserUsecase) int64 {
+	return time.Now().Unix() / int64(u.Config.TOTPPeriod/time.Second)
+}
+
+// newTOTPTestUsecase สร้าง UserUsecase ที่ไม่ต้องรอ backoff หลังจากยืนยันรหัส TOTP ไม่สำเร็จ
+func newTOTPTestUsecase(t *testing.T) *UserUsecase {
+	t.Helper()
+	u := newTestUsecase(t)
+	u.Config.LoginBackoffBase = 0 // ไม่ต้องรอหลังจากยืนยันไม่สำเร็จ
+	return u
+}
+
+func TestTOTPEnrollmentRequiresAuthorization(t *testing.T) {
+	u := newTOTPTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	bob := mustCreateUser(t, u, "bobby", u.Constants.RoleUser)
+	encoded, _, err := u.BeginTOTPEnrollment(alice, alice.ID, "")
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment error = %v", err)
+	}
+	secret, _ := totpEncoding.DecodeString(encoded)
+	code := generateTOTP(secret, currentTOTPCounter(u), u.Config.TOTPDigits)
+
+	if err := u.ConfirmTOTPEnrollment(bob, alice.ID, code); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("ConfirmTOTPEnrollment by other user error = %v, want ErrPermissionDenied", err)
+	}
+	if err := u.DisableTOTP(bob, alice.ID, code); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Errorf("DisableTOTP by other user error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestCompleteSecondFactorRejectsReplay(t *testing.T) {
+	u := newTOTPTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	secret := enrollTOTP(t, u, alice.ID)
+
+	_, err := u.Login("alice", testPassword)
+	var required *SecondFactorRequiredError
+	if !errors.As(err, &required) {
+		t.Fatalf("Login error = %v, want SecondFactorRequiredError", err)
+	}
+	code := generateTOTP(secret, currentTOTPCounter(u), u.Config.TOTPDigits)
+
+	var successes atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ { // ส่งรหัสเดียวกันพร้อมกันหลายครั้ง ต้องสำเร็จเพียงครั้งเดียว
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := u.CompleteSecondFactor(required.Challenge, code); err == nil {
+				successes.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := successes.Load(); got != 1 {
+		t.Errorf("successful logins with the same code = %d, want 1", got)
+	}
+}
+
+func TestCompleteSecondFactorRejectsDisabledAccount(t *testing.T) {
+	u := newTOTPTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	secret := enrollTOTP(t, u, alice.ID)
+
+	_, err := u.Login("alice", testPassword)
+	var required *SecondFactorRequiredError
+	if !errors.As(err, &required) {
+		t.Fatalf("Login error = %v, want SecondFactorRequiredError", err)
+	}
+	if err := u.DisableUser(admin, alice.ID); err != nil {
+		t.Fatalf("DisableUser error = %v", err)
+	}
+
+	code := generateTOTP(secret, currentTOTPCounter(u), u.Config.TOTPDigits)
+	if _, err := u.CompleteSecondFactor(required.Challenge, code); !errors.Is(err, u.Constants.ErrAccountDisabled) {
+		t.Errorf("CompleteSecondFactor error = %v, want ErrAccountDisabled", err)
+	}
+}
+
+func TestTOTPReenrollmentRequiresCurrentCode(t *testing.T) {
+	u := newTOTPTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	secret := enrollTOTP(t, u, alice.ID)
+
+	if _, _, err := u.BeginTOTPEnrollment(alice, alice.ID, ""); !errors.Is(err, u.Constants.ErrInvalidTOTPCode) {
+		t.Fatalf("BeginTOTPEnrollment without code error = %v, want ErrInvalidTOTPCode", err)
+	}
+	if got := mustGetUser(t, u, alice.ID).FailedLoginAttempts; got != 1 {
+		t.Errorf("FailedLoginAttempts = %d, want 1", got)
+	}
+
+	code := generateTOTP(secret, currentTOTPCounter(u), u.Config.TOTPDigits)
+	if _, _, err := u.BeginTOTPEnrollment(alice, alice.ID, code); err != nil {
+		t.Fatalf("BeginTOTPEnrollment with current code error = %v", err)
+	}
+	if _, _, err := u.BeginTOTPEnrollment(alice, alice.ID, code); !errors.Is(err, u.Constants.ErrInvalidTOTPCode) {
+		t.Errorf("BeginTOTPEnrollment with reused code error = %v, want ErrInvalidTOTPCode", err)
+	}
+}
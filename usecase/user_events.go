@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"sync"
+)
+
+// UserEventHandler ฟังก์ชันที่รับเหตุการณ์ของผู้ใช้
+type UserEventHandler func(event domain.UserEvent)
+
+// UserEventBus กระจายเหตุการณ์ของผู้ใช้ไปยังผู้ที่สมัครรับไว้ ตามลำดับที่สมัคร
+// handler ถูกเรียกใน goroutine ของผู้ส่ง จึงไม่ควรทำงานที่ใช้เวลานาน
+type UserEventBus struct {
+	mu       sync.RWMutex       // ใช้เพื่อให้สมัครรับและส่งเหตุการณ์พร้อมกันได้อย่างปลอดภัย
+	handlers []UserEventHandler // ผู้ที่สมัครรับเหตุการณ์
+}
+
+// NewUserEventBus สร้าง UserEventBus ที่ยังไม่มีผู้สมัครรับ
+func NewUserEventBus() *UserEventBus {
+	return &UserEventBus{}
+}
+
+// Subscribe สมัครรับเหตุการณ์ทุกชนิด handler ต้องตรวจสอบ event.Type เอง
+func (b *UserEventBus) Subscribe(handler UserEventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish ส่งเหตุการณ์ให้ผู้ที่สมัครรับทุกคน
+func (b *UserEventBus) Publish(event domain.UserEvent) {
+	b.mu.RLock()
+	handlers := b.handlers // คัดลอกรายการไว้ เพื่อให้ handler สมัครรับเพิ่มได้โดยไม่ติดล็อก
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
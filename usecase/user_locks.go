@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"errors"
+	"sync"
+)
+
+// errUnchanged ใช้ภายใน modifyUser เมื่อฟังก์ชันแก้ไขพบว่าไม่มีข้อมูลที่ต้องบันทึก
+var errUnchanged = errors.New("user unchanged")
+
+// userLocks ล็อกแยกตามผู้ใช้ เพื่อให้การอ่าน-แก้ไข-บันทึกข้อมูลของผู้ใช้คนเดียวกันเกิดขึ้นทีละรายการ
+// โดยไม่ทำให้ผู้ใช้คนอื่นต้องรอ ค่าเริ่มต้น (zero value) พร้อมใช้งาน
+type userLocks struct {
+	mu    sync.Mutex          // ป้องกันการเข้าถึง locks
+	locks map[int64]*userLock // ล็อกของผู้ใช้ที่กำลังถูกใช้งาน
+}
+
+// userLock ล็อกของผู้ใช้หนึ่งคน พร้อมจำนวนผู้ที่ถือหรือรอล็อกอยู่ เพื่อลบออกจาก map เมื่อไม่มีผู้ใช้งาน
+type userLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock ล็อกผู้ใช้ id และคืนค่าฟังก์ชันสำหรับปลดล็อก
+func (l *userLocks) lock(id int64) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[int64]*userLock)
+	}
+	entry, exists := l.locks[id]
+	if !exists {
+		entry = &userLock{}
+		l.locks[id] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
+
+	entry.mu.Lock()
+	return func() {
+		entry.mu.Unlock()
+
+		l.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 { // ไม่มีผู้ใดรอล็อกนี้แล้ว
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// modifyUser อ่านข้อมูลล่าสุดของผู้ใช้ id แก้ไขสำเนาด้วย modify แล้วบันทึก โดยถือล็อกของผู้ใช้ไว้ตลอด
+// เพื่อไม่ให้การแก้ไขพร้อมกัน เช่น การนับครั้งที่เข้าสู่ระบบไม่สำเร็จ เขียนทับกันจนข้อมูลสูญหาย
+// หาก modify คืนค่า errUnchanged จะไม่บันทึกและคืนค่าข้อมูลล่าสุด ข้อผิดพลาดอื่นจาก modify จะถูกคืนค่าโดยไม่บันทึก
+func (u *UserUsecase) modifyUser(id int64, modify func(user *domain.User) error) (*domain.User, error) {
+	unlock := u.userLocks.lock(id)
+	defer unlock()
+
+	current, err := u.UserRepo.GetByID(id) // อ่านข้อมูลล่าสุดภายใต้ล็อก
+	if err != nil {
+		return nil, err
+	}
+	updated := *current // คัดลอกข้อมูลผู้ใช้ก่อนแก้ไข
+	if err := modify(&updated); err != nil {
+		if errors.Is(err, errUnchanged) {
+			return current, nil
+		}
+		return nil, err
+	}
+	if err := u.UserRepo.Update(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
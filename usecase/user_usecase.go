@@ -3,9 +3,14 @@ package usecase
 import (
 	"Basic_login/domain"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"time"
+	"unicode"
 
 	"golang.org/x/crypto/argon2"
 )
@@ -17,6 +22,45 @@ type Config struct {
 	ArgonMemory  uint32 // จํานวนหน่วยความจำใน kilobytes
 	ArgonKeyLen  uint32 // ความยาวของ key
 	ArgonThreads uint8  // จำนวนเธรด (threads) ที่จะใช้ในการประมวลผลแฮช เพื่อเพิ่มประสิทธิภาพในการคำนวณ
+
+	SessionTokenLength int           // ความยาวของโทเคนเซสชันเป็น bytes
+	SessionTTL         time.Duration // อายุสูงสุดของเซสชันนับจากเวลาที่สร้าง
+	SessionIdleTimeout time.Duration // ระยะเวลาที่เซสชันจะหมดอายุหากไม่มีการใช้งาน
+
+	AccessTokenTTL  time.Duration // อายุของ access token (JWT)
+	RefreshTokenTTL time.Duration // อายุของ refresh token
+	TokenIssuer     string        // ชื่อผู้ออกโทเคน (iss) ที่ใส่ไว้ใน JWT
+
+	GenericLoginErrors bool // หากเป็น true Login จะคืนค่า ErrInvalidCredentials แทนการบอกว่าไม่พบผู้ใช้หรือรหัสผ่านผิด
+
+	MaxFailedLogins  int           // จำนวนครั้งที่เข้าสู่ระบบไม่สำเร็จติดต่อกันก่อนล็อกบัญชี (0 คือไม่ล็อก)
+	LockoutDuration  time.Duration // ระยะเวลาล็อกบัญชีครั้งแรก และเพิ่มเป็นสองเท่าทุกครั้งที่ถูกล็อกซ้ำ
+	MaxLockout       time.Duration // ระยะเวลาล็อกบัญชีสูงสุด
+	LoginBackoffBase time.Duration // ระยะเวลารอขั้นต่ำหลังเข้าสู่ระบบไม่สำเร็จ และเพิ่มเป็นสองเท่าทุกครั้งที่ไม่สำเร็จ
+	LoginBackoffMax  time.Duration // ระยะเวลารอสูงสุดระหว่างการลองเข้าสู่ระบบ
+
+	RateLimits map[string]domain.RateLimit // ขีดจำกัดอัตราการเรียกต่อผู้เรียกของแต่ละการดำเนินการ
+
+	SecretKey       []byte        // กุญแจขนาด 32 bytes สำหรับเข้ารหัส secret ที่เก็บไว้และลงลายมือชื่อ challenge
+	TOTPIssuer      string        // ชื่อผู้ออกที่แสดงในแอปยืนยันตัวตน
+	TOTPDigits      int           // จำนวนหลักของรหัส TOTP
+	TOTPPeriod      time.Duration // ระยะเวลาของรหัส TOTP แต่ละรหัส
+	TOTPSkew        int           // จำนวนช่วงเวลาก่อนและหลังที่ยอมรับ เพื่อรองรับนาฬิกาที่คลาดเคลื่อน
+	SecondFactorTTL time.Duration // อายุของ challenge สำหรับขั้นตอนที่สองของการเข้าสู่ระบบ
+
+	RecoveryCodeCount int // จำนวนรหัสกู้คืนที่สร้างในแต่ละชุด
+
+	PasswordResetTTL     time.Duration // อายุของโทเคนรีเซ็ตรหัสผ่าน
+	EmailVerificationTTL time.Duration // อายุของโทเคนยืนยันอีเมล
+
+	DeletedUserRetention time.Duration // ระยะเวลาเก็บบัญชีที่ถูกลบแบบ soft delete ก่อนลบถาวร
+
+	UsernameReservation time.Duration // ระยะเวลาที่ชื่อผู้ใช้เดิมถูกสงวนไว้หลังเปลี่ยนชื่อ (0 คือไม่สงวน)
+
+	UsernameMinLength  int                   // จำนวนตัวอักษร (rune) ขั้นต่ำของชื่อผู้ใช้หลัง canonicalize
+	UsernameMaxLength  int                   // จำนวนตัวอักษร (rune) สูงสุดของชื่อผู้ใช้หลัง canonicalize
+	UsernameScripts    []*unicode.RangeTable // ระบบการเขียน (script) ที่อนุญาตในชื่อผู้ใช้ ชื่อหนึ่งต้องใช้ script เดียวเท่านั้น
+	UsernameExtraChars string                // ตัวอักษรเพิ่มเติมที่อนุญาตได้กับทุก script เช่น ตัวเลขและเครื่องหมาย
 }
 
 // ฟังก์ชัน DefaultConfig สำหรับส่งคืนค่าโครงสร้าง Config ที่มีการตั้งค่าพื้นฐานสำหรับ argon2
@@ -27,6 +71,52 @@ func DefaultConfig() *Config {
 		ArgonThreads: 4,         //  ตั้งค่าเป็น 4 ซึ่งหมายถึงจำนวนเธรดที่ใช้ในการประมวลผลแฮช เพื่อให้สามารถคำนวณได้เร็วขึ้นในระบบที่รองรับหลายเธรด
 		ArgonKeyLen:  32,        // ตั้งค่าเป็น 32 ซึ่งระบุความยาวของคีย์ที่ได้จากการแฮชเป็น 32 bytes
 		SaltLength:   16,        // ตั้งค่าเป็น 16 ซึ่งหมายถึงความยาวของ "salt" ที่จะใช้ในการแฮช
+
+		SessionTokenLength: 32,               // ตั้งค่าเป็น 32 bytes สำหรับโทเคนเซสชัน
+		SessionTTL:         24 * time.Hour,   // เซสชันมีอายุสูงสุด 24 ชั่วโมง
+		SessionIdleTimeout: 30 * time.Minute, // เซสชันหมดอายุหากไม่มีการใช้งานเกิน 30 นาที
+
+		AccessTokenTTL:  15 * time.Minute,   // access token มีอายุ 15 นาที
+		RefreshTokenTTL: 7 * 24 * time.Hour, // refresh token มีอายุ 7 วัน
+		TokenIssuer:     "Basic_login",      // ชื่อผู้ออกโทเคน
+
+		GenericLoginErrors: false, // คืนค่าข้อผิดพลาดที่ระบุสาเหตุโดยตรง
+
+		MaxFailedLogins:  5,                // ล็อกบัญชีเมื่อเข้าสู่ระบบไม่สำเร็จ 5 ครั้งติดต่อกัน
+		LockoutDuration:  15 * time.Minute, // ล็อกบัญชีครั้งแรก 15 นาที
+		MaxLockout:       24 * time.Hour,   // ล็อกบัญชีนานสุด 24 ชั่วโมง
+		LoginBackoffBase: time.Second,      // รอ 1 วินาทีหลังเข้าสู่ระบบไม่สำเร็จครั้งแรก
+		LoginBackoffMax:  30 * time.Second, // รอนานสุด 30 วินาที
+
+		RateLimits: map[string]domain.RateLimit{
+			OperationLogin:         {Capacity: 10, RefillEvery: 6 * time.Second}, // เข้าสู่ระบบได้ 10 ครั้งติดกัน แล้วเติม 1 ครั้งทุก 6 วินาที
+			OperationCreateUser:    {Capacity: 5, RefillEvery: time.Minute},      // สร้างผู้ใช้ได้ 5 ครั้งติดกัน แล้วเติม 1 ครั้งทุกนาที
+			OperationResetPassword: {Capacity: 3, RefillEvery: 5 * time.Minute},  // รีเซ็ตรหัสผ่านได้ 3 ครั้งติดกัน แล้วเติม 1 ครั้งทุก 5 นาที
+			OperationSecondFactor:  {Capacity: 5, RefillEvery: 30 * time.Second}, // ยืนยันขั้นตอนที่สองได้ 5 ครั้งติดกัน แล้วเติม 1 ครั้งทุก 30 วินาที
+			OperationCompleteReset: {Capacity: 5, RefillEvery: time.Minute},      // ใช้โทเคนรีเซ็ตรหัสผ่านได้ 5 ครั้งติดกัน แล้วเติม 1 ครั้งทุกนาที
+			OperationVerifyEmail:   {Capacity: 5, RefillEvery: time.Minute},      // ยืนยันอีเมลได้ 5 ครั้งติดกัน แล้วเติม 1 ครั้งทุกนาที
+		},
+
+		SecretKey:       nil,              // ต้องกำหนดเองก่อนใช้งาน TOTP
+		TOTPIssuer:      "Basic_login",    // ชื่อผู้ออกที่แสดงในแอป
+		TOTPDigits:      6,                // รหัส 6 หลัก
+		TOTPPeriod:      30 * time.Second, // รหัสเปลี่ยนทุก 30 วินาที
+		TOTPSkew:        1,                // ยอมรับรหัสก่อนและหลัง 1 ช่วงเวลา
+		SecondFactorTTL: 5 * time.Minute,  // ต้องยืนยันขั้นตอนที่สองภายใน 5 นาที
+
+		RecoveryCodeCount: 10, // สร้างรหัสกู้คืนครั้งละ 10 รหัส
+
+		PasswordResetTTL:     30 * time.Minute, // โทเคนรีเซ็ตรหัสผ่านมีอายุ 30 นาที
+		EmailVerificationTTL: 24 * time.Hour,   // โทเคนยืนยันอีเมลมีอายุ 24 ชั่วโมง
+
+		DeletedUserRetention: 30 * 24 * time.Hour, // เก็บบัญชีที่ถูกลบไว้ 30 วันก่อนลบถาวร
+
+		UsernameReservation: 30 * 24 * time.Hour, // สงวนชื่อเดิมไว้ 30 วัน เพื่อป้องกันการสวมรอย
+
+		UsernameMinLength:  5,                                                  // ชื่อผู้ใช้ต้องมีอย่างน้อย 5 ตัวอักษร
+		UsernameMaxLength:  20,                                                 // ชื่อผู้ใช้ต้องมีไม่เกิน 20 ตัวอักษร
+		UsernameScripts:    []*unicode.RangeTable{unicode.Latin, unicode.Thai}, // อนุญาตอักษรละตินและอักษรไทย
+		UsernameExtraChars: "0123456789._-",                                    // ตัวเลขอารบิกและเครื่องหมาย . _ -
 	}
 }
 
@@ -36,8 +126,43 @@ type Constants struct {
 	RoleUser                 string // ค่าที่ใช้สำหรับบทบาทผู้ใช้
 	ErrUserNotFound          error  // ข้อความข้อผิดพลาดที่แสดงเมื่อไม่พบผู้ใช้ในระบบ
 	ErrInvalidPassword       error  // ข้อความข้อผิดพลาดที่แสดงเมื่อรหัสผ่านไม่ถูกต้อง
+	ErrInvalidCredentials    error  // ข้อความข้อผิดพลาดรวมที่แสดงเมื่อเข้าสู่ระบบไม่สำเร็จโดยไม่ระบุสาเหตุ
 	ErrUsernameTooLong       error  // ข้อความข้อผิดพลาดที่แสดงเมื่อชื่อผู้ใช้ที่ป้อนยาวเกินกว่าที่กำหนด
 	ErrUsernameAlreadyExists error  // ข้อความข้อผิดพลาดที่แสดงเมื่อมีการพยายามสร้างชื่อผู้ใช้ที่มีอยู่แล้วในระบบ
+	ErrSessionNotFound       error  // ข้อความข้อผิดพลาดที่แสดงเมื่อไม่พบเซสชันหรือเซสชันถูกยกเลิกแล้ว
+	ErrSessionExpired        error  // ข้อความข้อผิดพลาดที่แสดงเมื่อเซสชันหมดอายุ
+	ErrInvalidToken          error  // ข้อความข้อผิดพลาดที่แสดงเมื่อโทเคนไม่ถูกต้องหรือลายมือชื่อไม่ผ่านการตรวจสอบ
+	ErrTokenExpired          error  // ข้อความข้อผิดพลาดที่แสดงเมื่อโทเคนหมดอายุ
+	ErrTokenReused           error  // ข้อความข้อผิดพลาดที่แสดงเมื่อมีการนำ refresh token ที่ใช้แล้วกลับมาใช้ซ้ำ
+	ErrPasswordPolicy        error  // ข้อความข้อผิดพลาดที่แสดงเมื่อรหัสผ่านไม่ผ่านนโยบายรหัสผ่าน
+	ErrPermissionDenied      error  // ข้อความข้อผิดพลาดที่แสดงเมื่อผู้ใช้ไม่มีสิทธิ์ดำเนินการ
+	ErrAccountLocked         error  // ข้อความข้อผิดพลาดที่แสดงเมื่อบัญชีถูกล็อกชั่วคราวจากการเข้าสู่ระบบไม่สำเร็จหลายครั้ง
+	ErrRateLimited           error  // ข้อความข้อผิดพลาดที่แสดงเมื่อผู้เรียกเรียกใช้บ่อยเกินขีดจำกัด
+	ErrSecondFactorRequired  error  // ข้อความข้อผิดพลาดที่แสดงเมื่อต้องยืนยันตัวตนขั้นตอนที่สอง
+	ErrMustChangePassword    error  // ข้อความข้อผิดพลาดที่แสดงเมื่อผู้ใช้ต้องเปลี่ยนรหัสผ่านก่อนใช้งาน
+	ErrInvalidTOTPCode       error  // ข้อความข้อผิดพลาดที่แสดงเมื่อรหัส TOTP ไม่ถูกต้องหรือถูกใช้ไปแล้ว
+	ErrTOTPNotEnabled        error  // ข้อความข้อผิดพลาดที่แสดงเมื่อผู้ใช้ยังไม่ได้เปิดใช้ TOTP
+	ErrTOTPNotPending        error  // ข้อความข้อผิดพลาดที่แสดงเมื่อไม่มี secret ที่รอการยืนยัน
+	ErrInvalidChallenge      error  // ข้อความข้อผิดพลาดที่แสดงเมื่อ challenge ของขั้นตอนที่สองไม่ถูกต้องหรือหมดอายุ
+	ErrInvalidRecoveryCode   error  // ข้อความข้อผิดพลาดที่แสดงเมื่อรหัสกู้คืนไม่ถูกต้องหรือถูกใช้ไปแล้ว
+	ErrInvalidResetToken     error  // ข้อความข้อผิดพลาดที่แสดงเมื่อโทเคนรีเซ็ตรหัสผ่านไม่ถูกต้อง ถูกใช้แล้ว หรือหมดอายุ
+	ErrInvalidEmail          error  // ข้อความข้อผิดพลาดที่แสดงเมื่ออีเมลไม่ถูกต้อง
+	ErrEmailAlreadyExists    error  // ข้อความข้อผิดพลาดที่แสดงเมื่ออีเมลถูกใช้โดยผู้ใช้อื่นแล้ว
+	ErrInvalidEmailToken     error  // ข้อความข้อผิดพลาดที่แสดงเมื่อโทเคนยืนยันอีเมลไม่ถูกต้อง ถูกใช้แล้ว หรือหมดอายุ
+	ErrAccountDisabled       error  // ข้อความข้อผิดพลาดที่แสดงเมื่อบัญชีถูกระงับ
+	ErrAccountPending        error  // ข้อความข้อผิดพลาดที่แสดงเมื่อบัญชียังรอการเปิดใช้งาน
+	ErrAccountDeleted        error  // ข้อความข้อผิดพลาดที่แสดงเมื่อบัญชีถูกลบแล้ว
+	ErrUsernameReserved      error  // ข้อความข้อผิดพลาดที่แสดงเมื่อชื่อผู้ใช้ถูกสงวนไว้
+	ErrInvalidUsername       error  // ข้อความข้อผิดพลาดที่แสดงเมื่อชื่อผู้ใช้มีตัวอักษรที่ไม่อนุญาต
+	ErrUsernameMixedScript   error  // ข้อความข้อผิดพลาดที่แสดงเมื่อชื่อผู้ใช้ผสมหลายระบบการเขียน
+	ErrUsernameNotAllowed    error  // ข้อความข้อผิดพลาดที่แสดงเมื่อชื่อผู้ใช้อยู่ในรายชื่อที่สงวนไว้
+	ErrRoleNotFound          error  // ข้อความข้อผิดพลาดที่แสดงเมื่อไม่พบบทบาท
+	ErrRoleExists            error  // ข้อความข้อผิดพลาดที่แสดงเมื่อมีบทบาทนี้อยู่แล้ว
+	ErrRoleInUse             error  // ข้อความข้อผิดพลาดที่แสดงเมื่อลบบทบาทที่ยังถูกใช้อยู่
+	ErrInvalidRole           error  // ข้อความข้อผิดพลาดที่แสดงเมื่อข้อมูลบทบาทไม่ถูกต้อง
+	ErrGroupNotFound         error  // ข้อความข้อผิดพลาดที่แสดงเมื่อไม่พบกลุ่ม
+	ErrGroupExists           error  // ข้อความข้อผิดพลาดที่แสดงเมื่อมีกลุ่มชื่อนี้อยู่แล้ว
+	ErrInvalidGroup          error  // ข้อความข้อผิดพลาดที่แสดงเมื่อข้อมูลกลุ่มไม่ถูกต้อง
 }
 
 // ฟังก์ชัน NewConstants เพื่อสร้างและคืนค่าให้โครงสร้าง Constants ที่มีการกำหนดค่าคงที่สำหรับบทบาทของผู้ใช้และข้อความแสดงข้อผิดพลาด
@@ -47,38 +172,94 @@ func NewConstants() *Constants {
 		RoleUser:                 "user",                                                          // ตั้งค่าเป็น "user" เพื่อระบุบทบาทของผู้ใช้ทั่วไป
 		ErrUserNotFound:          errors.New("user not found"),                                    // ใช้ errors.New("user not found") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าไม่พบผู้ใช้ในระบบ
 		ErrInvalidPassword:       errors.New("invalid password"),                                  // ใช้ errors.New("invalid password") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่า รหัสผ่านที่ป้อนไม่ถูกต้อง
+		ErrInvalidCredentials:    errors.New("invalid credentials"),                               // ใช้ errors.New("invalid credentials") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าชื่อผู้ใช้หรือรหัสผ่านไม่ถูกต้อง
 		ErrUsernameTooLong:       errors.New("username must be between 5 and 20 characters long"), // ใช้ errors.New("username must be between 5 and 20 characters long") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าชื่อผู้ใช้ต้องมีความยาวระหว่าง 5 ถึง 20 ตัวอักษร
 		ErrUsernameAlreadyExists: errors.New("the username is already taken"),                     // ใช้ errors.New("the username is already taken") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าชื่อผู้ใช้ที่พยายามลงทะเบียนมีอยู่แล้วในระบบ
+		ErrSessionNotFound:       errors.New("session not found"),                                 // ใช้ errors.New("session not found") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าไม่พบเซสชัน
+		ErrSessionExpired:        errors.New("session expired"),                                   // ใช้ errors.New("session expired") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าเซสชันหมดอายุแล้ว
+		ErrInvalidToken:          errors.New("invalid token"),                                     // ใช้ errors.New("invalid token") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าโทเคนไม่ถูกต้อง
+		ErrTokenExpired:          errors.New("token expired"),                                     // ใช้ errors.New("token expired") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าโทเคนหมดอายุแล้ว
+		ErrTokenReused:           errors.New("refresh token reuse detected"),                      // ใช้ errors.New("refresh token reuse detected") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่ามีการใช้ refresh token ซ้ำ
+		ErrPasswordPolicy:        errors.New("password does not meet the password policy"),        // ใช้ errors.New("password does not meet the password policy") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่ารหัสผ่านไม่ผ่านนโยบาย
+		ErrPermissionDenied:      errors.New("permission denied"),                                 // ใช้ errors.New("permission denied") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าผู้ใช้ไม่มีสิทธิ์ดำเนินการ
+		ErrAccountLocked:         errors.New("account is temporarily locked"),                     // ใช้ errors.New("account is temporarily locked") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าบัญชีถูกล็อกชั่วคราว
+		ErrRateLimited:           errors.New("too many requests, please try again later"),         // ใช้ errors.New("too many requests, please try again later") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าเรียกใช้บ่อยเกินไป
+		ErrSecondFactorRequired:  errors.New("second factor required"),                            // ใช้ errors.New("second factor required") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าต้องยืนยันตัวตนขั้นตอนที่สอง
+		ErrMustChangePassword:    errors.New("password change required"),                          // ใช้ errors.New("password change required") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าต้องเปลี่ยนรหัสผ่านก่อนใช้งาน
+		ErrInvalidTOTPCode:       errors.New("invalid authentication code"),                       // ใช้ errors.New("invalid authentication code") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่ารหัส TOTP ไม่ถูกต้อง
+		ErrTOTPNotEnabled:        errors.New("two-factor authentication is not enabled"),          // ใช้ errors.New("two-factor authentication is not enabled") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่ายังไม่ได้เปิดใช้ TOTP
+		ErrTOTPNotPending:        errors.New("no pending two-factor enrollment"),                  // ใช้ errors.New("no pending two-factor enrollment") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าไม่มีการลงทะเบียนที่รอยืนยัน
+		ErrInvalidChallenge:      errors.New("invalid or expired login challenge"),                // ใช้ errors.New("invalid or expired login challenge") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่า challenge ไม่ถูกต้อง
+		ErrInvalidRecoveryCode:   errors.New("invalid recovery code"),                             // ใช้ errors.New("invalid recovery code") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่ารหัสกู้คืนไม่ถูกต้อง
+		ErrInvalidResetToken:     errors.New("invalid or expired password reset token"),           // ใช้ errors.New("invalid or expired password reset token") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าโทเคนรีเซ็ตรหัสผ่านไม่ถูกต้อง
+		ErrInvalidEmail:          errors.New("invalid email address"),                             // ใช้ errors.New("invalid email address") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าอีเมลไม่ถูกต้อง
+		ErrEmailAlreadyExists:    errors.New("the email address is already in use"),               // ใช้ errors.New("the email address is already in use") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าอีเมลถูกใช้แล้ว
+		ErrInvalidEmailToken:     errors.New("invalid or expired email verification token"),       // ใช้ errors.New("invalid or expired email verification token") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าโทเคนยืนยันอีเมลไม่ถูกต้อง
+		ErrAccountDisabled:       errors.New("account is disabled"),                               // ใช้ errors.New("account is disabled") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าบัญชีถูกระงับ
+		ErrAccountPending:        errors.New("account is pending activation"),                     // ใช้ errors.New("account is pending activation") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าบัญชียังไม่เปิดใช้งาน
+		ErrAccountDeleted:        errors.New("account has been deleted"),                          // ใช้ errors.New("account has been deleted") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าบัญชีถูกลบแล้ว
+		ErrUsernameReserved:      errors.New("the username is reserved"),                          // ใช้ errors.New("the username is reserved") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าชื่อผู้ใช้ถูกสงวนไว้
+		ErrInvalidUsername:       errors.New("username contains characters that are not allowed"), // ใช้ errors.New("username contains characters that are not allowed") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าชื่อผู้ใช้มีตัวอักษรที่ไม่อนุญาต
+		ErrUsernameMixedScript:   errors.New("username must not mix writing systems"),             // ใช้ errors.New("username must not mix writing systems") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าชื่อผู้ใช้ผสมหลายระบบการเขียน ซึ่งอาจใช้ปลอมเป็นชื่ออื่นได้
+		ErrUsernameNotAllowed:    errors.New("the username is not allowed"),                       // ใช้ errors.New("the username is not allowed") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าชื่อผู้ใช้อยู่ในรายชื่อที่สงวนไว้สำหรับระบบ
+		ErrRoleNotFound:          errors.New("role not found"),                                    // ใช้ errors.New("role not found") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าไม่พบบทบาท
+		ErrRoleExists:            errors.New("role already exists"),                               // ใช้ errors.New("role already exists") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่ามีบทบาทนี้อยู่แล้ว
+		ErrRoleInUse:             errors.New("role is still in use"),                              // ใช้ errors.New("role is still in use") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่ายังมีผู้ใช้หรือบทบาทอื่นใช้บทบาทนี้อยู่
+		ErrInvalidRole:           errors.New("invalid role definition"),                           // ใช้ errors.New("invalid role definition") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าสิทธิ์ไม่รู้จักหรือการสืบทอดวนซ้ำ
+		ErrGroupNotFound:         errors.New("group not found"),                                   // ใช้ errors.New("group not found") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าไม่พบกลุ่ม
+		ErrGroupExists:           errors.New("group already exists"),                              // ใช้ errors.New("group already exists") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่ามีกลุ่มชื่อนี้อยู่แล้ว
+		ErrInvalidGroup:          errors.New("invalid group definition"),                          // ใช้ errors.New("invalid group definition") เพื่อสร้างข้อผิดพลาดที่จะแจ้งว่าชื่อกลุ่มว่างหรือสิทธิ์ไม่รู้จัก
 	}
 }
 
 // โครงสร้าง interface UserRepository ใช้สำหรับการดำเนินการกับผู้ใช้ในระบบ
 type UserRepository interface {
-	GetByID(id int64) (*domain.User, error)              // ฟังก์ชันนี้ใช้เพื่อดึงข้อมูลผู้ใช้จากฐานข้อมูลตาม id ที่ระบุ โดยจะคืนค่าผู้ใช้ (*domain.User) และข้อผิดพลาด (error) หากไม่พบผู้ใช้หรือเกิดข้อผิดพลาดในการดึงข้อมูล
-	Create(user *domain.User) error                      // ฟังก์ชันนี้ใช้เพื่อสร้างผู้ใช้ใหม่ในฐานข้อมูล โดยรับพารามิเตอร์เป็นผู้ใช้ (*domain.User) และจะคืนค่าข้อผิดพลาดหากเกิดปัญหาในการสร้าง
-	GetByUsername(username string) (*domain.User, error) // ฟังก์ชันนี้ใช้เพื่อดึงข้อมูลผู้ใช้จากฐานข้อมูลตามชื่อผู้ใช้ (username) โดยคืนค่าผู้ใช้และข้อผิดพลาดตามปกติ
-	GetAll() ([]*domain.User, error)                     // ฟังก์ชันนี้ใช้เพื่อดึงข้อมูลผู้ใช้ทั้งหมดจากฐานข้อมูล โดยคืนค่าลิสต์ของผู้ใช้ ([]*domain.User) และข้อผิดพลาด
-	Update(user *domain.User) error                      // ฟังก์ชันนี้ใช้เพื่อปรับปรุงข้อมูลผู้ใช้ที่มีอยู่ในฐานข้อมูล โดยรับพารามิเตอร์เป็นผู้ใช้และคืนค่าข้อผิดพลาดหากเกิดปัญหา
-	SendChatMessage(sender, message string)              // ฟังก์ชันนี้ใช้สำหรับส่งข้อความแชทจากผู้ส่ง (sender) ไปยังข้อความ (message) ที่ระบุ
-	LeaveChat(username string)                           // ฟังก์ชันนี้ใช้สำหรับให้ผู้ใช้ (username) ออกจากการแชท
+	GetByID(id int64) (*domain.User, error)                    // ฟังก์ชันนี้ใช้เพื่อดึงข้อมูลผู้ใช้จากฐานข้อมูลตาม id ที่ระบุ โดยจะคืนค่าผู้ใช้ (*domain.User) และข้อผิดพลาด (error) หากไม่พบผู้ใช้หรือเกิดข้อผิดพลาดในการดึงข้อมูล
+	Create(user *domain.User) error                            // ฟังก์ชันนี้ใช้เพื่อสร้างผู้ใช้ใหม่ในฐานข้อมูล โดยรับพารามิเตอร์เป็นผู้ใช้ (*domain.User) และจะคืนค่าข้อผิดพลาดหากเกิดปัญหาในการสร้าง
+	GetByUsername(username string) (*domain.User, error)       // ฟังก์ชันนี้ใช้เพื่อดึงข้อมูลผู้ใช้จากฐานข้อมูลตามชื่อผู้ใช้ (username) โดยคืนค่าผู้ใช้และข้อผิดพลาดตามปกติ
+	GetByEmail(email string) (*domain.User, error)             // ฟังก์ชันนี้ใช้เพื่อดึงข้อมูลผู้ใช้จากฐานข้อมูลตามอีเมลที่ normalize แล้ว
+	GetAll() ([]*domain.User, error)                           // ฟังก์ชันนี้ใช้เพื่อดึงข้อมูลผู้ใช้ทั้งหมดจากฐานข้อมูล โดยคืนค่าลิสต์ของผู้ใช้ ([]*domain.User) และข้อผิดพลาด
+	Update(user *domain.User) error                            // ฟังก์ชันนี้ใช้เพื่อปรับปรุงข้อมูลผู้ใช้ที่มีอยู่ในฐานข้อมูล โดยรับพารามิเตอร์เป็นผู้ใช้และคืนค่าข้อผิดพลาดหากเกิดปัญหา
+	Delete(id int64) error                                     // ฟังก์ชันนี้ใช้เพื่อลบผู้ใช้ออกจากฐานข้อมูลอย่างถาวรตาม id ที่ระบุ
+	Rename(id int64, newUsername string) (*domain.User, error) // ฟังก์ชันนี้ใช้เพื่อเปลี่ยนชื่อผู้ใช้และปรับดัชนีทั้งหมดพร้อมกัน โดยบันทึกชื่อเดิมไว้ในประวัติ
 }
 
 // โครงสร้าง UserUsecase ใช้สำหรับการดำเนินการที่เกี่ยวข้องกับผู้ใช้ในระบบ
 type UserUsecase struct { //  โครงสร้าง UserUsecase มีฟิลด์ต่างๆ เช่น UserRepo (interface UserRepository) สำหรับการเข้าถึงข้อมูลผู้ใช้, Config (*Config) สำหรับการกำหนดค่า, Logger (*log.Logger) สำหรับการเขียนล็อก, และ Constants (*Constants) สำหรับค่าคงที่ที่ใช้ในระบบ
-	UserRepo  UserRepository // ฟิลด์สำหรับการเข้าถึงข้อมูลผู้ใช้
-	Config    *Config        // ฟิลด์สำหรับการกำหนดค่า
-	Logger    *log.Logger    // ฟิลด์สำหรับการเขียนล็อก
-	Constants *Constants     // ฟิลด์สำหรับค่าคงที่ที่ใช้ในระบบ
+	UserRepo    UserRepository         // ฟิลด์สำหรับการเข้าถึงข้อมูลผู้ใช้
+	SessionRepo SessionRepository      // ฟิลด์สำหรับการเข้าถึงข้อมูลเซสชัน
+	RefreshRepo RefreshTokenRepository // ฟิลด์สำหรับยกเลิก refresh token ของ TokenService (nil คือไม่ได้ใช้ TokenService)
+	RoleRepo    RoleRepository         // ฟิลด์สำหรับการเข้าถึงบทบาทและชุดสิทธิ์
+	GroupRepo   GroupRepository        // ฟิลด์สำหรับการเข้าถึงกลุ่มของผู้ใช้
+	Config      *Config                // ฟิลด์สำหรับการกำหนดค่า
+	Policy      PasswordPolicy         // ฟิลด์สำหรับนโยบายรหัสผ่าน สามารถเปลี่ยนเป็นนโยบายอื่นได้
+	Reserved    *ReservedUsernames     // ฟิลด์สำหรับรายชื่อผู้ใช้ที่สงวนไว้ (nil คือไม่ตรวจสอบ)
+	Notifier    Notifier               // ฟิลด์สำหรับส่งการแจ้งเตือนถึงผู้ใช้ เช่น โทเคนรีเซ็ตรหัสผ่าน
+	Events      *UserEventBus          // ฟิลด์สำหรับส่งเหตุการณ์ของผู้ใช้ เช่น การสร้างผู้ใช้ใหม่ ให้ส่วนอื่นของระบบ
+	Logger      *log.Logger            // ฟิลด์สำหรับการเขียนล็อก
+	Constants   *Constants             // ฟิลด์สำหรับค่าคงที่ที่ใช้ในระบบ
+
+	dummyHashOnce sync.Once // ใช้สร้าง dummyHash เพียงครั้งเดียว
+	dummyHash     string    // แฮชหลอกที่ใช้ตรวจสอบรหัสผ่านเมื่อไม่พบผู้ใช้ เพื่อให้เวลาที่ใช้เท่ากัน
+	userLocks     userLocks // ล็อกแยกตามผู้ใช้ สำหรับการแก้ไขข้อมูลที่ต้องอ่านค่าเดิมก่อน เช่น ตัวนับต่างๆ
 }
 
 // NewUserUsecase สร้างและคืนค่า UserUsecase ใหม่
-func NewUserUsecase(repo UserRepository, config *Config, logger *log.Logger, constants *Constants) *UserUsecase {
-	return &UserUsecase{
-		UserRepo:  repo,      // กำหนดค่า UserRepo จากพารามิเตอร์ repo
-		Config:    config,    // กำหนดค่า Config จากพารามิเตอร์ config
-		Logger:    logger,    // กำหนดค่า Logger จากพารามิเตอร์ logger
-		Constants: constants, // กำหนดค่า Constants จากพารามิเตอร์ constants
+func NewUserUsecase(repo UserRepository, sessionRepo SessionRepository, roleRepo RoleRepository, groupRepo GroupRepository, config *Config, logger *log.Logger, constants *Constants) *UserUsecase {
+	u := &UserUsecase{
+		UserRepo:    repo,                          // กำหนดค่า UserRepo จากพารามิเตอร์ repo
+		SessionRepo: sessionRepo,                   // กำหนดค่า SessionRepo จากพารามิเตอร์ sessionRepo
+		RoleRepo:    roleRepo,                      // กำหนดค่า RoleRepo จากพารามิเตอร์ roleRepo
+		GroupRepo:   groupRepo,                     // กำหนดค่า GroupRepo จากพารามิเตอร์ groupRepo
+		Config:      config,                        // กำหนดค่า Config จากพารามิเตอร์ config
+		Policy:      NewDefaultPasswordPolicy(),    // ใช้นโยบายรหัสผ่านพื้นฐาน
+		Reserved:    NewDefaultReservedUsernames(), // ใช้รายชื่อที่สงวนไว้พื้นฐาน
+		Events:      NewUserEventBus(),             // ยังไม่มีผู้สมัครรับเหตุการณ์
+		Logger:      logger,                        // กำหนดค่า Logger จากพารามิเตอร์ logger
+		Constants:   constants,                     // กำหนดค่า Constants จากพารามิเตอร์ constants
 	}
+	u.seedDefaultRoles() // เพิ่มบทบาทพื้นฐาน admin และ user หากยังไม่มี
+	return u
 }
 
 // GetUserByID ดึงข้อมูลผู้ใช้ตาม ID
@@ -86,35 +267,143 @@ func (u *UserUsecase) GetUserByID(id int64) (*domain.User, error) {
 	return u.UserRepo.GetByID(id) // เรียกใช้ฟังก์ชัน GetByID จาก UserRepo เพื่อดึงข้อมูลผู้ใช้
 }
 
-// Update ปรับปรุงข้อมูลผู้ใช้
-func (u *UserUsecase) Update(user *domain.User) error {
-	return u.UserRepo.Update(user) // เรียกใช้ฟังก์ชัน Update จาก UserRepo เพื่อปรับปรุงข้อมูลผู้ใช้
+// Update ปรับปรุงข้อมูลผู้ใช้ตาม allow-list ได้แก่ บทบาท สถานะ และอีเมล ข้อมูลอื่นใน user จะถูกละเว้น
+// (ใช้ Rename, ChangePassword, ResetPassword, TOTP หรือ UnlockAccount สำหรับข้อมูลส่วนนั้น)
+// การเปลี่ยนบทบาทต้องมีสิทธิ์ PermRoleAssign และสิทธิ์ทุกข้อของบทบาทใหม่ การเปลี่ยนสถานะต้องมีสิทธิ์ PermUserDisable เพิ่มเติม
+// และการเปลี่ยนอีเมลจะผ่านการตรวจสอบแบบเดียวกับ SetEmail
+func (u *UserUsecase) Update(actor, user *domain.User) error {
+	existing, err := u.UserRepo.GetByID(user.ID) // ดึงข้อมูลผู้ใช้เดิมเป็นฐานของการปรับปรุง
+	if err != nil {
+		return err
+	}
+	resource := domain.UserResource(existing)
+	if err := u.Authorize(actor, domain.PermUserUpdate, resource); err != nil {
+		return err
+	}
+
+	var statusChanged bool
+	var status domain.AccountStatus
+	updated, err := u.modifyUser(existing.ID, func(updated *domain.User) error { // เริ่มจากข้อมูลล่าสุด แล้วคัดลอกเฉพาะฟิลด์ที่อนุญาตให้แก้ไข
+		current := *updated // ข้อมูลล่าสุดก่อนแก้ไข ใช้เปรียบเทียบว่าฟิลด์ใดเปลี่ยน
+
+		if user.Role != current.Role { // ป้องกันการเพิ่มสิทธิ์ให้ตนเอง
+			if err := u.authorizeGrant(actor, resource, []string{user.Role}, nil); err != nil {
+				return err
+			}
+			if !u.IsValidRole(user.Role) {
+				return u.Constants.ErrRoleNotFound
+			}
+			updated.Role = user.Role
+		}
+		status = user.EffectiveStatus()
+		statusChanged = status != current.EffectiveStatus()
+		if statusChanged {
+			if err := u.Authorize(actor, domain.PermUserDisable, resource); err != nil {
+				return err
+			}
+			if err := u.applyStatus(updated, status); err != nil {
+				return err
+			}
+		}
+		if user.Email != current.Email {
+			return u.applyEmail(updated, user.Email)
+		}
+		return nil
+	})
+	if err != nil {
+		normalized, _ := NormalizeEmail(user.Email)
+		return u.emailConflict(existing.ID, normalized, err)
+	}
+	if statusChanged && status != domain.StatusActive { // บัญชีที่ใช้งานไม่ได้ต้องไม่มีเซสชันค้างอยู่
+		u.revokeUserSessions(updated)
+	}
+	return nil
 }
 
-// SendChatMessage ส่งข้อความแชท
-func (u *UserUsecase) SendChatMessage(sender, message string) {
-	u.UserRepo.SendChatMessage(sender, message) // เรียกใช้ฟังก์ชัน SendChatMessage จาก UserRepo เพื่อส่งข้อความแชท
+// CreateUser ลงทะเบียนผู้ใช้ใหม่ด้วยตนเอง ซึ่งใช้ได้เฉพาะบทบาท RoleUser ยกเว้นผู้ใช้คนแรกของระบบที่กำหนดบทบาทใดก็ได้
+// การสร้างผู้ใช้ในนามผู้อื่นหรือกำหนดบทบาทอื่นให้ใช้ CreateUserAs
+func (u *UserUsecase) CreateUser(user *domain.User, role string) error {
+	if role != u.Constants.RoleUser {
+		users, err := u.UserRepo.GetAll()
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 { // มีผู้ใช้อยู่แล้ว จึงไม่ใช่การตั้งค่าระบบครั้งแรก
+			return u.Constants.ErrPermissionDenied
+		}
+	}
+	return u.createUser(user, role, false)
 }
 
-// LeaveChat ให้ผู้ใช้ (username) ออกจากการแชท
-func (u *UserUsecase) LeaveChat(username string) {
-	u.UserRepo.LeaveChat(username) // เรียกใช้ฟังก์ชัน LeaveChat จาก UserRepo เพื่อให้ผู้ใช้ออกจากการแชท
+// CreateUserAs ให้ผู้ที่มีสิทธิ์ PermUserCreate สร้างผู้ใช้ใหม่ การกำหนดบทบาทอื่นนอกจาก RoleUser ต้องมีสิทธิ์ PermRoleAssign
+// และสิทธิ์ทุกข้อของบทบาทนั้น
+func (u *UserUsecase) CreateUserAs(actor, user *domain.User, role string) error {
+	if err := u.authorizeCreate(actor, role); err != nil {
+		return err
+	}
+	return u.createUser(user, role, false)
 }
 
-// CreateUser สร้างผู้ใช้ใหม่
-func (u *UserUsecase) CreateUser(user *domain.User, role string) error {
-	// ตรวจสอบชื่อผู้ใช้ หากไม่ถูกต้องให้คืนค่าข้อผิดพลาด
-	if err := validateUsername(user.Username, u.Constants); err != nil {
+// authorizeCreate ตรวจสอบสิทธิ์การสร้างผู้ใช้ที่มีบทบาท role
+func (u *UserUsecase) authorizeCreate(actor *domain.User, role string) error {
+	resource := domain.Resource{Kind: domain.ResourceUser}
+	if err := u.Authorize(actor, domain.PermUserCreate, resource); err != nil {
+		return err
+	}
+	if role != u.Constants.RoleUser {
+		return u.authorizeGrant(actor, resource, []string{role}, nil)
+	}
+	return nil
+}
+
+// createUser สร้างผู้ใช้ใหม่ โดย allowReserved ใช้เมื่อผู้ดูแลระบบอนุญาตให้ใช้ชื่อที่สงวนไว้
+func (u *UserUsecase) createUser(user *domain.User, role string, allowReserved bool) error {
+	// ตรวจสอบชื่อผู้ใช้และแปลงเป็นรูปแบบมาตรฐาน หากไม่ถูกต้องให้คืนค่าข้อผิดพลาด
+	username, err := u.validateUsername(user.Username)
+	if err != nil {
+		return err
+	}
+	if !allowReserved {
+		if err := u.checkReservedUsername(username); err != nil { // ชื่อที่สงวนไว้ เช่น "admin" ใช้ไม่ได้
+			return err
+		}
+	}
+	user.Username = username                                             // เก็บชื่อผู้ใช้ในรูปแบบมาตรฐาน เพื่อให้ "Admin" และ "admin" เป็นชื่อเดียวกัน
+	if err := u.checkUsernameReservation(user.Username, 0); err != nil { // ชื่อที่เพิ่งถูกเปลี่ยนออกไปยังใช้ไม่ได้
 		return err
 	}
 
-	user.Role = role                                                   // กำหนดบทบาทให้กับผู้ใช้
-	hashedPassword, salt, err := HashPassword(user.Password, u.Config) // แฮชรหัสผ่านและสร้าง salt
+	// ตรวจสอบอีเมล (ถ้ามี) และ normalize ก่อนบันทึก
+	if user.Email != "" {
+		email, ok := NormalizeEmail(user.Email)
+		if !ok {
+			return u.Constants.ErrInvalidEmail
+		}
+		if _, err := u.UserRepo.GetByEmail(email); err == nil { // อีเมลถูกใช้โดยผู้ใช้อื่นแล้ว
+			return u.Constants.ErrEmailAlreadyExists
+		}
+		user.Email = email
+		user.EmailVerified = false
+	}
+
+	// ตรวจสอบรหัสผ่านตามนโยบาย หากไม่ผ่านให้คืนค่าข้อผิดพลาดพร้อมรายการกฎที่ไม่ผ่าน
+	if err := u.checkPasswordPolicy(user.Username, user.Password); err != nil {
+		return err
+	}
+
+	if !u.IsValidRole(role) { // บทบาทต้องมีอยู่ใน RoleRepo
+		return u.Constants.ErrRoleNotFound
+	}
+	user.Role = role // กำหนดบทบาทให้กับผู้ใช้
+	if user.Status == "" {
+		user.Status = domain.StatusActive // ผู้ใช้ใหม่ใช้งานได้ทันที เว้นแต่ผู้เรียกกำหนดสถานะเป็นอย่างอื่น เช่น StatusPending
+	}
+	hashedPassword, err := HashPassword(user.Password, u.Config) // แฮชรหัสผ่านในรูปแบบ PHC
 	if err != nil {
 		return err // หากเกิดข้อผิดพลาดในการแฮชคืนค่าข้อผิดพลาด
 	}
-	user.Password = hashedPassword // กำหนดรหัสผ่านที่แฮชแล้ว
-	user.Salt = salt               // กำหนดค่า salt
+	user.Password = hashedPassword // กำหนดรหัสผ่านที่แฮชแล้ว ซึ่งมี salt และพารามิเตอร์อยู่ในตัว
+	user.Salt = nil                // salt แยกใช้เฉพาะกับแฮชรูปแบบเดิม
 
 	if err := u.UserRepo.Create(user); err != nil {
 		if errors.Is(err, u.Constants.ErrUsernameAlreadyExists) {
@@ -124,38 +413,159 @@ func (u *UserUsecase) CreateUser(user *domain.User, role string) error {
 	}
 
 	u.Logger.Printf("User created: %s\n", user.Username) // บันทึกการสร้างผู้ใช้ในล็อก
-	return nil                                           // คืนค่า nil หากสร้างผู้ใช้สำเร็จ
+	// แจ้งส่วนอื่นของระบบ เช่น ห้องสนทนา ว่ามีผู้ใช้ใหม่
+	if u.Events != nil {
+		u.Events.Publish(domain.UserEvent{
+			Type:       domain.UserCreated,
+			UserID:     user.ID,
+			Username:   user.Username,
+			OccurredAt: time.Now(),
+		})
+	}
+	return nil // คืนค่า nil หากสร้างผู้ใช้สำเร็จ
 }
 
 // Login ทำการเข้าสู่ระบบของผู้ใช้
 func (u *UserUsecase) Login(username, password string) (*domain.User, error) {
-	user, err := u.UserRepo.GetByUsername(username) // ดึงข้อมูลผู้ใช้จากฐานข้อมูลตามชื่อผู้ใช้
+	user, err := u.findUser(username) // ดึงข้อมูลผู้ใช้จากฐานข้อมูลตามชื่อผู้ใช้หรืออีเมล
+	if err != nil {
+		ValidatePassword(password, u.getDummyHash(), nil, u.Config)       // แฮชรหัสผ่านหลอก เพื่อไม่ให้เวลาตอบกลับเปิดเผยว่าไม่มีผู้ใช้นี้
+		return nil, u.loginFailure(username, u.Constants.ErrUserNotFound) // หากไม่พบผู้ใช้ให้คืนค่าข้อผิดพลาด
+	}
+
+	user, err = u.beginLoginAttempt(user, time.Now()) // ตรวจสอบการล็อกบัญชีและนับการพยายามครั้งนี้ไว้ก่อน
 	if err != nil {
-		return nil, u.Constants.ErrUserNotFound // หากไม่พบผู้ใช้ให้คืนค่าข้อผิดพลาด
+		ValidatePassword(password, u.getDummyHash(), nil, u.Config) // แฮชรหัสผ่านหลอก เพื่อไม่ให้เวลาตอบกลับเปิดเผยว่าบัญชีถูกล็อก
+		return nil, u.loginFailure(username, err)
 	}
 
 	if !ValidatePassword(password, user.Password, user.Salt, u.Config) {
-		return nil, u.Constants.ErrInvalidPassword // หากรหัสผ่านไม่ถูกต้องให้คืนค่าข้อผิดพลาด
+		return nil, u.loginFailure(username, u.Constants.ErrInvalidPassword) // หากรหัสผ่านไม่ถูกต้องให้คืนค่าข้อผิดพลาด (นับไว้แล้วใน beginLoginAttempt)
+	}
+	user = u.resetFailedLogins(user) // เข้าสู่ระบบสำเร็จ ล้างจำนวนครั้งที่ไม่สำเร็จ
+
+	if NeedsRehash(user.Password, u.Config) { // แฮชที่เก็บไว้อ่อนกว่าค่าปัจจุบัน ให้แฮชใหม่ด้วยรหัสผ่านที่เพิ่งตรวจสอบผ่าน
+		user = u.rehashPassword(user, password)
+	}
+
+	if err := u.checkAccountStatus(user); err != nil { // บัญชีที่ไม่ได้อยู่ในสถานะใช้งานเข้าสู่ระบบไม่ได้
+		return nil, u.loginFailure(username, err)
+	}
+
+	if user.TOTPEnabled { // ผู้ใช้เปิดใช้การยืนยันตัวตนสองขั้นตอน ต้องยืนยันรหัส TOTP ก่อน
+		return nil, u.secondFactorRequired(user)
+	}
+	return u.completeLogin(user)
+}
+
+// completeLogin จบขั้นตอนการเข้าสู่ระบบหลังยืนยันตัวตนครบทุกขั้นตอน
+// ผู้ใช้ที่ต้องเปลี่ยนรหัสผ่านจะได้รับ PasswordChangeRequiredError แทนข้อมูลผู้ใช้
+func (u *UserUsecase) completeLogin(user *domain.User) (*domain.User, error) {
+	if user.MustChangePassword { // ผู้ดูแลรีเซ็ตรหัสผ่านไว้ ต้องเปลี่ยนรหัสผ่านก่อนใช้งาน
+		return nil, u.passwordChangeRequired(user)
 	}
 
 	u.Logger.Printf("Login successful: %s with role: %s\n", user.Username, user.Role) // บันทึกการเข้าสู่ระบบของผู้ใช้ในล็อก
 	return user, nil                                                                  // คืนค่าผู้ใช้และ nil หากเข้าสู่ระบบสำเร็จ
 }
 
-// HashPassword สร้างแฮชสำหรับรหัสผ่านที่เป็นข้อความธรรมดาที่ให้มา
-func HashPassword(password string, config *Config) (string, []byte, error) {
+// checkPasswordPolicy ตรวจสอบรหัสผ่านด้วย Policy โดยข้อผิดพลาดที่คืนค่าจะเป็นทั้ง ErrPasswordPolicy และ *PasswordPolicyError
+func (u *UserUsecase) checkPasswordPolicy(username, password string) error {
+	if u.Policy == nil { // ไม่ได้กำหนดนโยบายรหัสผ่าน
+		return nil
+	}
+	if err := u.Policy.Validate(username, password); err != nil {
+		return fmt.Errorf("%w: %w", u.Constants.ErrPasswordPolicy, err)
+	}
+	return nil
+}
+
+// loginFailure บันทึกสาเหตุที่เข้าสู่ระบบไม่สำเร็จลงในล็อก และคืนค่าข้อผิดพลาดที่จะส่งให้ผู้เรียก
+// หากเปิด GenericLoginErrors จะคืนค่า ErrInvalidCredentials แทนกรณีไม่พบผู้ใช้หรือรหัสผ่านผิด
+func (u *UserUsecase) loginFailure(username string, reason error) error {
+	u.Logger.Printf("Login failed: %s: %v\n", username, reason) // บันทึกสาเหตุจริงในล็อก
+	if u.Config.GenericLoginErrors && (reason == u.Constants.ErrUserNotFound || reason == u.Constants.ErrInvalidPassword) {
+		return u.Constants.ErrInvalidCredentials
+	}
+	return reason
+}
+
+// getDummyHash คืนค่าแฮชหลอกที่สร้างด้วยพารามิเตอร์ปัจจุบัน สำหรับใช้เมื่อไม่พบผู้ใช้
+func (u *UserUsecase) getDummyHash() string {
+	u.dummyHashOnce.Do(func() {
+		hash, err := HashPassword("dummy-password", u.Config) // สร้างแฮชด้วยพารามิเตอร์เดียวกับผู้ใช้จริง
+		if err != nil {
+			u.Logger.Printf("Failed to create dummy hash: %v\n", err)
+			return
+		}
+		u.dummyHash = hash
+	})
+	return u.dummyHash
+}
+
+// rehashPassword แฮชรหัสผ่านใหม่ด้วยค่าใน Config ปัจจุบันและบันทึกผ่าน modifyUser
+// หากแฮชหรือบันทึกไม่สำเร็จจะบันทึกล็อกและคืนค่าผู้ใช้เดิม เพื่อไม่ให้การเข้าสู่ระบบล้มเหลว
+func (u *UserUsecase) rehashPassword(user *domain.User, password string) *domain.User {
+	hashedPassword, err := HashPassword(password, u.Config) // แฮชรหัสผ่านด้วยพารามิเตอร์ปัจจุบัน
+	if err != nil {
+		u.Logger.Printf("Failed to rehash password for %s: %v\n", user.Username, err)
+		return user
+	}
+
+	updated, err := u.modifyUser(user.ID, func(updated *domain.User) error {
+		if updated.Password != user.Password { // รหัสผ่านถูกเปลี่ยนไปแล้วระหว่างแฮช ห้ามเขียนทับ
+			return errUnchanged
+		}
+		updated.Password = hashedPassword // กำหนดแฮชใหม่
+		updated.Salt = nil                // แฮชรูปแบบ PHC ไม่ใช้ salt แยก
+		return nil
+	})
+	if err != nil {
+		u.Logger.Printf("Failed to store rehashed password for %s: %v\n", user.Username, err)
+		return user
+	}
+
+	u.Logger.Printf("Password rehashed with current parameters: %s\n", user.Username) // บันทึกการแฮชใหม่ในล็อก
+	return updated
+}
+
+// HashPassword สร้างแฮชสำหรับรหัสผ่านที่เป็นข้อความธรรมดาที่ให้มา ในรูปแบบ PHC ที่เก็บพารามิเตอร์และ salt ไว้ในตัว
+func HashPassword(password string, config *Config) (string, error) {
 	salt, err := generateSalt(config.SaltLength) // สร้าง salt สำหรับรหัสผ่าน
 	if err != nil {
-		return "", nil, err // หากเกิดข้อผิดพลาดในการสร้าง salt คืนค่าข้อผิดพลาด
+		return "", err // หากเกิดข้อผิดพลาดในการสร้าง salt คืนค่าข้อผิดพลาด
 	}
 	hashedPassword := argon2.IDKey([]byte(password), salt, config.ArgonTime, config.ArgonMemory, config.ArgonThreads, config.ArgonKeyLen) // แฮชรหัสผ่านโดยใช้ argon2
-	return base64.RawStdEncoding.EncodeToString(hashedPassword), salt, nil                                                                // คืนค่าแฮชที่เข้ารหัสเป็น base64 พร้อมกับ salt
+	// คืนค่าแฮชในรูปแบบ $argon2id$v=19$m=...,t=...,p=...$salt$hash
+	return encodePasswordHash(&passwordHash{
+		Algorithm: "argon2id",
+		Version:   argon2.Version,
+		Memory:    config.ArgonMemory,
+		Time:      config.ArgonTime,
+		Threads:   config.ArgonThreads,
+		Salt:      salt,
+		Key:       hashedPassword,
+	}), nil
 }
 
 // ValidatePassword เปรียบเทียบรหัสผ่านที่เป็นข้อความธรรมดากับรหัสผ่านที่แฮชเก็บไว้
-func ValidatePassword(password, hashedPassword string, salt []byte, config *Config) bool {
-	hash := argon2.IDKey([]byte(password), salt, config.ArgonTime, config.ArgonMemory, config.ArgonThreads, config.ArgonKeyLen) // แฮชรหัสผ่านที่ให้มา
-	return hashedPassword == base64.RawStdEncoding.EncodeToString(hash)                                                         // คืนค่าผลลัพธ์ว่าแฮชที่เก็บไว้ตรงกับแฮชที่สร้างจากรหัสผ่านหรือไม่
+// แฮชรูปแบบ PHC จะใช้พารามิเตอร์ที่เก็บไว้ในแฮช ส่วนแฮชรูปแบบเดิมจะใช้ salt ที่เก็บแยกและพารามิเตอร์จาก config
+func ValidatePassword(password, hashedPassword string, legacySalt []byte, config *Config) bool {
+	if !isPHCHash(hashedPassword) { // แฮชรูปแบบเดิม
+		hash := argon2.IDKey([]byte(password), legacySalt, config.ArgonTime, config.ArgonMemory, config.ArgonThreads, config.ArgonKeyLen) // แฮชรหัสผ่านที่ให้มา
+		stored, err := base64.RawStdEncoding.DecodeString(hashedPassword)                                                                 // ถอดรหัสแฮชที่เก็บไว้
+		if err != nil {
+			return false
+		}
+		return subtle.ConstantTimeCompare(stored, hash) == 1 // เปรียบเทียบแฮชโดยใช้เวลาคงที่
+	}
+
+	stored, err := decodePasswordHash(hashedPassword) // ถอดรหัสพารามิเตอร์จากแฮชที่เก็บไว้
+	if err != nil {
+		return false // แฮชไม่ถูกต้อง ถือว่ารหัสผ่านไม่ตรงกัน
+	}
+	hash := argon2.IDKey([]byte(password), stored.Salt, stored.Time, stored.Memory, stored.Threads, uint32(len(stored.Key))) // แฮชรหัสผ่านด้วยพารามิเตอร์เดิม
+	return subtle.ConstantTimeCompare(stored.Key, hash) == 1                                                                 // เปรียบเทียบแฮชโดยใช้เวลาคงที่
 }
 
 // generateSalt สร้าง salt แบบสุ่ม
@@ -166,11 +576,3 @@ func generateSalt(length int) ([]byte, error) {
 	}
 	return salt, nil // คืนค่า salt ที่สร้างขึ้น
 }
-
-// validateUsername ตรวจสอบชื่อผู้ใช้ว่าตรงตามข้อกำหนดหรือไม่
-func validateUsername(username string, constants *Constants) error {
-	if len(username) < 5 || len(username) > 20 { // ตรวจสอบความยาวของชื่อผู้ใช้
-		return constants.ErrUsernameTooLong // หากชื่อผู้ใช้ยาวเกินไปหรือสั้นเกินไปให้คืนค่าข้อผิดพลาด
-	}
-	return nil // คืนค่า nil หากชื่อผู้ใช้ถูกต้อง
-}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"Basic_login/domain"
+	"Basic_login/repository"
+	"errors"
+	"io"
+	"log"
+	"testing"
+)
+
+// testPassword รหัสผ่านที่ผ่านนโยบายรหัสผ่านเริ่มต้น
+const testPassword = "Str0ng!Passw0rd#x"
+
+// newTestUsecase สร้าง UserUsecase ที่ใช้ repository ในหน่วยความจำ และลดค่าใช้จ่ายของ Argon2 เพื่อให้การทดสอบเร็วขึ้น
+func newTestUsecase(t *testing.T) *UserUsecase {
+	t.Helper()
+	config := DefaultConfig()
+	config.ArgonMemory = 1024
+	config.ArgonTime = 1
+	config.SecretKey = make([]byte, 32) // ใช้ลงลายมือชื่อ challenge และเข้ารหัส secret ของ TOTP
+	return NewUserUsecase(
+		repository.NewInMemoryUserRepository(),
+		repository.NewInMemorySessionRepository(),
+		repository.NewInMemoryRoleRepository(),
+		repository.NewInMemoryGroupRepository(),
+		config,
+		log.New(io.Discard, "", 0),
+		NewConstants(),
+	)
+}
+
+// mustCreateUser สร้างผู้ใช้ที่มีบทบาท role และหยุดการทดสอบหากไม่สำเร็จ
+func mustCreateUser(t *testing.T, u *UserUsecase, username, role string) *domain.User {
+	t.Helper()
+	user := &domain.User{Username: username, Password: testPassword}
+	if err := u.createUser(user, role, true); err != nil {
+		t.Fatalf("createUser(%q) error = %v", username, err)
+	}
+	return user
+}
+
+// mustGetUser ดึงข้อมูลล่าสุดของผู้ใช้จาก UserRepo และหยุดการทดสอบหากไม่สำเร็จ
+func mustGetUser(t *testing.T, u *UserUsecase, id int64) *domain.User {
+	t.Helper()
+	user, err := u.UserRepo.GetByID(id)
+	if err != nil {
+		t.Fatalf("GetByID(%d) error = %v", id, err)
+	}
+	return user
+}
+
+func TestUpdateIgnoresProtectedFields(t *testing.T) {
+	u := newTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+	original := mustGetUser(t, u, alice.ID)
+
+	edited := *original
+	edited.EmailVerified = true
+	edited.MustChangePassword = true
+	edited.FailedLoginAttempts = 99
+	edited.TOTPLastCounter = 12345
+	edited.ResetTokenHash = "forged"
+	edited.PreviousUsernames = []domain.UsernameChange{{Username: "root"}}
+	edited.Password = "plaintext"
+	if err := u.Update(alice, &edited); err != nil {
+		t.Fatalf("Update error = %v", err)
+	}
+
+	got := mustGetUser(t, u, alice.ID)
+	if got.EmailVerified || got.MustChangePassword || got.FailedLoginAttempts != 0 || got.TOTPLastCounter != 0 ||
+		got.ResetTokenHash != "" || len(got.PreviousUsernames) != 0 || got.Password != original.Password {
+		t.Errorf("Update changed protected fields: %+v", got)
+	}
+}
+
+func TestUpdateEmailIsNormalizedAndUnverified(t *testing.T) {
+	u := newTestUsecase(t)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	edited := *mustGetUser(t, u, alice.ID)
+	edited.Email = "  Alice@Example.COM "
+	edited.EmailVerified = true
+	if err := u.Update(alice, &edited); err != nil {
+		t.Fatalf("Update error = %v", err)
+	}
+	got := mustGetUser(t, u, alice.ID)
+	if got.Email != "alice@example.com" || got.EmailVerified {
+		t.Errorf("Update email = %q verified = %v, want normalized and unverified", got.Email, got.EmailVerified)
+	}
+
+	edited.Email = "not-an-email"
+	if err := u.Update(alice, &edited); !errors.Is(err, u.Constants.ErrInvalidEmail) {
+		t.Errorf("Update invalid email error = %v, want ErrInvalidEmail", err)
+	}
+}
+
+func TestUpdateRoleRequiresRoleAssign(t *testing.T) {
+	u := newTestUsecase(t)
+	admin := mustCreateUser(t, u, "admin", u.Constants.RoleAdmin)
+	alice := mustCreateUser(t, u, "alice", u.Constants.RoleUser)
+
+	edited := *mustGetUser(t, u, alice.ID)
+	edited.Role = u.Constants.RoleAdmin
+	if err := u.Update(alice, &edited); !errors.Is(err, u.Constants.ErrPermissionDenied) {
+		t.Fatalf("self role change error = %v, want ErrPermissionDenied", err)
+	}
+	if err := u.Update(admin, &edited); err != nil {
+		t.Fatalf("admin role change error = %v", err)
+	}
+	if got := mustGetUser(t, u, alice.ID); got.Role != u.Constants.RoleAdmin {
+		t.Errorf("role = %q, want %q", got.Role, u.Constants.RoleAdmin)
+	}
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/secure/precis"
+	"golang.org/x/text/unicode/norm"
+)
+
+// CanonicalUsername แปลงชื่อผู้ใช้เป็นรูปแบบมาตรฐานที่ใช้ตรวจสอบความซ้ำ โดยทำ NFKC แล้วใช้ PRECIS
+// UsernameCaseMapped (RFC 8265) ซึ่งแปลงความกว้างของตัวอักษรและตัวพิมพ์ใหญ่เป็นตัวพิมพ์เล็ก
+func CanonicalUsername(username string) (string, error) {
+	return precis.UsernameCaseMapped.String(norm.NFKC.String(username))
+}
+
+// validateUsername ตรวจสอบชื่อผู้ใช้ว่าตรงตามข้อกำหนดหรือไม่ และคืนค่าชื่อในรูปแบบมาตรฐาน
+// ความยาวนับเป็นจำนวนตัวอักษร (rune) ไม่ใช่ bytes เพื่อให้ชื่อภาษาไทยใช้ความยาวได้เท่ากับชื่อภาษาอังกฤษ
+func (u *UserUsecase) validateUsername(username string) (string, error) {
+	canonical, err := CanonicalUsername(username)
+	if err != nil { // PRECIS ไม่อนุญาตช่องว่าง ตัวควบคุม และตัวอักษรที่มองไม่เห็น
+		return "", u.Constants.ErrInvalidUsername
+	}
+
+	length := utf8.RuneCountInString(canonical) // ตรวจสอบความยาวของชื่อผู้ใช้
+	if length < u.Config.UsernameMinLength || length > u.Config.UsernameMaxLength {
+		return "", u.Constants.ErrUsernameTooLong // หากชื่อผู้ใช้ยาวเกินไปหรือสั้นเกินไปให้คืนค่าข้อผิดพลาด
+	}
+
+	// ทุกตัวอักษรต้องอยู่ในชุดที่อนุญาต และต้องมาจาก script เดียวกัน เพื่อป้องกันชื่อที่หน้าตาเหมือนกัน
+	// เช่น "аdmin" ที่ใช้ "а" จากอักษรซีริลลิก
+	script := -1
+	for _, r := range canonical {
+		if strings.ContainsRune(u.Config.UsernameExtraChars, r) { // ตัวอักษรเพิ่มเติมใช้ได้กับทุก script
+			continue
+		}
+		index := scriptIndex(r, u.Config.UsernameScripts)
+		if index < 0 {
+			return "", u.Constants.ErrInvalidUsername
+		}
+		if script >= 0 && script != index {
+			return "", u.Constants.ErrUsernameMixedScript
+		}
+		script = index
+	}
+	return canonical, nil // คืนค่าชื่อผู้ใช้ในรูปแบบมาตรฐานหากถูกต้อง
+}
+
+// scriptIndex คืนค่าลำดับของ script ที่ตัวอักษรนี้อยู่ใน scripts หรือ -1 หากไม่อยู่ในชุดที่อนุญาต
+func scriptIndex(r rune, scripts []*unicode.RangeTable) int {
+	if !unicode.In(r, unicode.L, unicode.M, unicode.Nd) { // อนุญาตเฉพาะตัวอักษร เครื่องหมายประกอบ และตัวเลข
+		return -1
+	}
+	for i, table := range scripts {
+		if unicode.Is(table, r) {
+			return i
+		}
+	}
+	return -1
+}